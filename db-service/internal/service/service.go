@@ -16,12 +16,15 @@ func NewChatService(storage storage.Storage) *ChatService {
 	}
 }
 
-func (s *ChatService) SaveChat(chatID string, messengerType storage.MessengerType) error {
+func (s *ChatService) SaveChat(chatID string, messengerType storage.MessengerType, threadID int) error {
 	if chatID == "" {
 		return fmt.Errorf("chat ID cannot be empty")
 	}
+	if threadID < 0 {
+		return fmt.Errorf("invalid thread ID %d", threadID)
+	}
 
-	return s.storage.Save(chatID, messengerType)
+	return s.storage.Save(chatID, messengerType, threadID)
 }
 
 func (s *ChatService) DeleteChat(chatID string) error {
@@ -32,6 +35,14 @@ func (s *ChatService) DeleteChat(chatID string) error {
 	return s.storage.Delete(chatID)
 }
 
+func (s *ChatService) MigrateChat(oldChatID string, newChatID string) error {
+	if oldChatID == "" || newChatID == "" {
+		return fmt.Errorf("chat ID cannot be empty")
+	}
+
+	return s.storage.Migrate(oldChatID, newChatID)
+}
+
 func (s *ChatService) ChatExists(chatID string) (bool, error) {
 	if chatID == "" {
 		return false, fmt.Errorf("chat ID cannot be empty")
@@ -44,6 +55,104 @@ func (s *ChatService) GetChatsByMessenger(messengerType storage.MessengerType) (
 	return s.storage.GetAllByMessenger(messengerType)
 }
 
+func (s *ChatService) GetThreads(messengerType storage.MessengerType) ([]storage.ChatThread, error) {
+	return s.storage.GetThreads(messengerType)
+}
+
+func (s *ChatService) SetChatActive(chatID string, active bool) error {
+	if chatID == "" {
+		return fmt.Errorf("chat ID cannot be empty")
+	}
+
+	return s.storage.SetActive(chatID, active)
+}
+
+func (s *ChatService) Stats() ([]storage.ChatStats, error) {
+	return s.storage.Stats()
+}
+
+func (s *ChatService) GetState(key string) (string, error) {
+	if key == "" {
+		return "", fmt.Errorf("state key cannot be empty")
+	}
+
+	return s.storage.GetState(key)
+}
+
+func (s *ChatService) SetState(key string, value string) error {
+	if key == "" {
+		return fmt.Errorf("state key cannot be empty")
+	}
+
+	return s.storage.SetState(key, value)
+}
+
+func (s *ChatService) MuteSource(chatID string, source string) error {
+	if chatID == "" {
+		return fmt.Errorf("chat ID cannot be empty")
+	}
+	if source == "" {
+		return fmt.Errorf("source cannot be empty")
+	}
+
+	return s.storage.MuteSource(chatID, source)
+}
+
+func (s *ChatService) GetMutedChats(source string) ([]int, error) {
+	return s.storage.GetMutedChats(source)
+}
+
+func (s *ChatService) SaveDeliveries(deliveries []storage.Delivery) error {
+	for _, d := range deliveries {
+		if d.ChatID == "" {
+			return fmt.Errorf("chat ID cannot be empty")
+		}
+
+		switch d.Target {
+		case storage.TargetChat, storage.TargetChannel:
+		default:
+			return fmt.Errorf("invalid delivery target %q", d.Target)
+		}
+	}
+
+	return s.storage.SaveDeliveries(deliveries)
+}
+
+func (s *ChatService) GetDeliveries(postID int64, messengerType storage.MessengerType) ([]storage.Delivery, error) {
+	if postID <= 0 {
+		return nil, fmt.Errorf("invalid post ID %d", postID)
+	}
+
+	return s.storage.GetDeliveries(postID, messengerType)
+}
+
+func (s *ChatService) RetractDeliveries(postID int64, messengerType storage.MessengerType, chatIDs []string) error {
+	if postID <= 0 {
+		return fmt.Errorf("invalid post ID %d", postID)
+	}
+
+	return s.storage.RetractDeliveries(postID, messengerType, chatIDs)
+}
+
+func (s *ChatService) GetLanguage(chatID string, messengerType storage.MessengerType) (string, error) {
+	if chatID == "" {
+		return "", fmt.Errorf("chat ID cannot be empty")
+	}
+
+	return s.storage.GetLanguage(chatID, messengerType)
+}
+
+func (s *ChatService) SetLanguage(chatID string, messengerType storage.MessengerType, lang string) error {
+	if chatID == "" {
+		return fmt.Errorf("chat ID cannot be empty")
+	}
+	if lang == "" {
+		return fmt.Errorf("language cannot be empty")
+	}
+
+	return s.storage.SetLanguage(chatID, messengerType, lang)
+}
+
 func (s *ChatService) Close() error {
 	return s.storage.Close()
 }
@@ -6,7 +6,7 @@ import (
 	"strconv"
 
 	sq "github.com/Masterminds/squirrel"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 )
 
 type Postgres struct {
@@ -49,17 +49,62 @@ func (p *Postgres) initSchema() error {
 	
 	-- Создаем индекс по полю messenger для быстрого поиска
 	CREATE INDEX IF NOT EXISTS idx_chat_entries_messenger ON chat_entries(messenger);
+
+	ALTER TABLE chat_entries ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+	ALTER TABLE chat_entries ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP;
+	ALTER TABLE chat_entries ADD COLUMN IF NOT EXISTS thread_id BIGINT NOT NULL DEFAULT 0;
+
+	CREATE TABLE IF NOT EXISTS bot_state (
+		key VARCHAR(255) PRIMARY KEY,
+		value TEXT NOT NULL,
+		updated_at TIMESTAMP DEFAULT NOW()
+	);
+
+	CREATE TABLE IF NOT EXISTS muted_sources (
+		chat_id VARCHAR(255) NOT NULL,
+		source VARCHAR(255) NOT NULL,
+		created_at TIMESTAMP DEFAULT NOW(),
+		PRIMARY KEY (chat_id, source)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_muted_sources_source ON muted_sources(source);
+
+	CREATE TABLE IF NOT EXISTS deliveries (
+		id BIGSERIAL PRIMARY KEY,
+		post_id BIGINT NOT NULL,
+		messenger VARCHAR(50) NOT NULL,
+		chat_id VARCHAR(255) NOT NULL,
+		target VARCHAR(16) NOT NULL,
+		status VARCHAR(16) NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMP DEFAULT NOW()
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_deliveries_post ON deliveries(post_id, messenger);
+
+	ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS message_id BIGINT NOT NULL DEFAULT 0;
+	ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS message_ids BIGINT[] NOT NULL DEFAULT '{}';
+	ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS caption BOOLEAN NOT NULL DEFAULT FALSE;
+	ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS updated_date TIMESTAMPTZ;
+
+	CREATE TABLE IF NOT EXISTS chat_languages (
+		chat_id VARCHAR(255) NOT NULL,
+		messenger VARCHAR(50) NOT NULL,
+		lang VARCHAR(8) NOT NULL,
+		updated_at TIMESTAMP DEFAULT NOW(),
+		PRIMARY KEY (chat_id, messenger)
+	);
 	`
 
 	_, err := p.db.Exec(query)
 	return err
 }
 
-func (p *Postgres) Save(chatID string, messengerType MessengerType) error {
+func (p *Postgres) Save(chatID string, messengerType MessengerType, threadID int) error {
 	query := p.psql.Insert("chat_entries").
-		Columns("id", "messenger", "created_at").
-		Values(chatID, messengerType, sq.Expr("NOW()")).
-		Suffix("ON CONFLICT (id) DO UPDATE SET messenger = EXCLUDED.messenger")
+		Columns("id", "messenger", "thread_id", "created_at").
+		Values(chatID, messengerType, threadID, sq.Expr("NOW()")).
+		Suffix("ON CONFLICT (id) DO UPDATE SET messenger = EXCLUDED.messenger, thread_id = EXCLUDED.thread_id, active = TRUE, deactivated_at = NULL")
 
 	_, err := query.RunWith(p.db).Exec()
 	if err != nil {
@@ -81,10 +126,78 @@ func (p *Postgres) Delete(chatID string) error {
 	return nil
 }
 
+// Migrate moves a chat and its settings to a new id, used when a Telegram
+// group is upgraded to a supergroup.
+func (p *Postgres) Migrate(oldChatID string, newChatID string) error {
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Keep the entry of the new chat if it is already subscribed.
+	chats := p.psql.Insert("chat_entries").
+		Columns("id", "messenger", "created_at", "active", "deactivated_at", "thread_id").
+		Select(sq.Select().
+			Column(sq.Expr("?", newChatID)).
+			Columns("messenger", "created_at", "active", "deactivated_at", "thread_id").
+			From("chat_entries").
+			Where(sq.Eq{"id": oldChatID})).
+		Suffix("ON CONFLICT (id) DO NOTHING")
+
+	if _, err := chats.RunWith(tx).Exec(); err != nil {
+		return fmt.Errorf("failed to migrate chat entry: %w", err)
+	}
+
+	muted := p.psql.Insert("muted_sources").
+		Columns("chat_id", "source", "created_at").
+		Select(sq.Select().
+			Column(sq.Expr("?", newChatID)).
+			Columns("source", "created_at").
+			From("muted_sources").
+			Where(sq.Eq{"chat_id": oldChatID})).
+		Suffix("ON CONFLICT (chat_id, source) DO NOTHING")
+
+	if _, err := muted.RunWith(tx).Exec(); err != nil {
+		return fmt.Errorf("failed to migrate muted sources: %w", err)
+	}
+
+	if _, err := p.psql.Delete("muted_sources").Where(sq.Eq{"chat_id": oldChatID}).RunWith(tx).Exec(); err != nil {
+		return fmt.Errorf("failed to delete old muted sources: %w", err)
+	}
+
+	language := p.psql.Insert("chat_languages").
+		Columns("chat_id", "messenger", "lang", "updated_at").
+		Select(sq.Select().
+			Column(sq.Expr("?", newChatID)).
+			Columns("messenger", "lang", "updated_at").
+			From("chat_languages").
+			Where(sq.Eq{"chat_id": oldChatID})).
+		Suffix("ON CONFLICT (chat_id, messenger) DO NOTHING")
+
+	if _, err := language.RunWith(tx).Exec(); err != nil {
+		return fmt.Errorf("failed to migrate chat language: %w", err)
+	}
+
+	if _, err := p.psql.Delete("chat_languages").Where(sq.Eq{"chat_id": oldChatID}).RunWith(tx).Exec(); err != nil {
+		return fmt.Errorf("failed to delete old chat language: %w", err)
+	}
+
+	if _, err := p.psql.Delete("chat_entries").Where(sq.Eq{"id": oldChatID}).RunWith(tx).Exec(); err != nil {
+		return fmt.Errorf("failed to delete old chat entry: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit chat migration: %w", err)
+	}
+
+	return nil
+}
+
 func (p *Postgres) Exists(chatID string) (bool, error) {
 	query := p.psql.Select("1").
 		From("chat_entries").
-		Where(sq.Eq{"id": chatID}).
+		Where(sq.Eq{"id": chatID, "active": true}).
 		Limit(1)
 
 	var exists int
@@ -102,7 +215,7 @@ func (p *Postgres) Exists(chatID string) (bool, error) {
 func (p *Postgres) GetAllByMessenger(messengerType MessengerType) ([]int, error) {
 	query := p.psql.Select("id").
 		From("chat_entries").
-		Where(sq.Eq{"messenger": messengerType}).
+		Where(sq.Eq{"messenger": messengerType, "active": true}).
 		OrderBy("created_at DESC")
 
 	rows, err := query.RunWith(p.db).Query()
@@ -134,6 +247,321 @@ func (p *Postgres) GetAllByMessenger(messengerType MessengerType) ([]int, error)
 	return Ids, nil
 }
 
+// GetThreads returns the active chats of a messenger subscribed in a forum
+// topic.
+func (p *Postgres) GetThreads(messengerType MessengerType) ([]ChatThread, error) {
+	query := p.psql.Select("id", "thread_id").
+		From("chat_entries").
+		Where(sq.Eq{"messenger": messengerType, "active": true}).
+		Where(sq.NotEq{"thread_id": 0})
+
+	rows, err := query.RunWith(p.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat threads: %w", err)
+	}
+	defer rows.Close()
+
+	var threads []ChatThread
+	for rows.Next() {
+		var t ChatThread
+		if err := rows.Scan(&t.ID, &t.ThreadID); err != nil {
+			return nil, fmt.Errorf("failed to scan chat thread: %w", err)
+		}
+		threads = append(threads, t)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over chat threads: %w", err)
+	}
+
+	return threads, nil
+}
+
+// SetActive marks a subscribed chat as reachable or not, e.g. when the bot
+// was blocked or kicked. Chats that aren't subscribed are left alone.
+func (p *Postgres) SetActive(chatID string, active bool) error {
+	query := p.psql.Update("chat_entries").
+		Set("active", active).
+		Where(sq.Eq{"id": chatID})
+
+	if active {
+		query = query.Set("deactivated_at", nil)
+	} else {
+		query = query.Set("deactivated_at", sq.Expr("NOW()"))
+	}
+
+	_, err := query.RunWith(p.db).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to set chat activity: %w", err)
+	}
+
+	return nil
+}
+
+// Stats counts active and inactive chats of every messenger.
+func (p *Postgres) Stats() ([]ChatStats, error) {
+	query := p.psql.Select(
+		"messenger",
+		"COUNT(*) FILTER (WHERE active)",
+		"COUNT(*) FILTER (WHERE NOT active)",
+	).
+		From("chat_entries").
+		GroupBy("messenger").
+		OrderBy("messenger")
+
+	rows, err := query.RunWith(p.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query chat stats: %w", err)
+	}
+	defer rows.Close()
+
+	var stats []ChatStats
+	for rows.Next() {
+		var s ChatStats
+		if err := rows.Scan(&s.Messenger, &s.Active, &s.Inactive); err != nil {
+			return nil, fmt.Errorf("failed to scan chat stats: %w", err)
+		}
+		stats = append(stats, s)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over chat stats: %w", err)
+	}
+
+	return stats, nil
+}
+
+func (p *Postgres) GetState(key string) (string, error) {
+	query := p.psql.Select("value").
+		From("bot_state").
+		Where(sq.Eq{"key": key}).
+		Limit(1)
+
+	var value string
+	err := query.RunWith(p.db).QueryRow().Scan(&value)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get bot state: %w", err)
+	}
+
+	return value, nil
+}
+
+func (p *Postgres) SetState(key string, value string) error {
+	query := p.psql.Insert("bot_state").
+		Columns("key", "value", "updated_at").
+		Values(key, value, sq.Expr("NOW()")).
+		Suffix("ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at")
+
+	_, err := query.RunWith(p.db).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to set bot state: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) MuteSource(chatID string, source string) error {
+	query := p.psql.Insert("muted_sources").
+		Columns("chat_id", "source", "created_at").
+		Values(chatID, source, sq.Expr("NOW()")).
+		Suffix("ON CONFLICT (chat_id, source) DO NOTHING")
+
+	_, err := query.RunWith(p.db).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to mute source: %w", err)
+	}
+
+	return nil
+}
+
+func (p *Postgres) GetMutedChats(source string) ([]int, error) {
+	query := p.psql.Select("chat_id").
+		From("muted_sources").
+		Where(sq.Eq{"source": source})
+
+	rows, err := query.RunWith(p.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query muted chats: %w", err)
+	}
+	defer rows.Close()
+
+	var Ids []int
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan chat ID: %w", err)
+		}
+
+		temp, err := strconv.Atoi(id)
+		if err != nil {
+			continue
+		}
+		Ids = append(Ids, temp)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over muted chats: %w", err)
+	}
+
+	return Ids, nil
+}
+
+// deliveriesPerInsert bounds the rows of one insert of deliveries, as a
+// statement takes at most 65535 parameters.
+const deliveriesPerInsert = 1000
+
+// SaveDeliveries inserts the deliveries in one transaction, a statement per
+// deliveriesPerInsert of them.
+func (p *Postgres) SaveDeliveries(deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	tx, err := p.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for start := 0; start < len(deliveries); start += deliveriesPerInsert {
+		query := p.psql.Insert("deliveries").
+			Columns("post_id", "messenger", "chat_id", "target", "status", "error", "message_id", "message_ids", "caption", "updated_date", "created_at")
+
+		for _, d := range deliveries[start:min(start+deliveriesPerInsert, len(deliveries))] {
+			var updated sql.NullTime
+			if !d.UpdatedDate.IsZero() {
+				updated = sql.NullTime{Time: d.UpdatedDate, Valid: true}
+			}
+
+			query = query.Values(d.PostID, d.Messenger, d.ChatID, d.Target, d.Status, d.Error, d.MessageID, pq.Array(messageIDs(d)), d.Caption, updated, sq.Expr("NOW()"))
+		}
+
+		if _, err := query.RunWith(tx).Exec(); err != nil {
+			return fmt.Errorf("failed to save deliveries: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliveries returns the latest successful delivery of a post to every
+// chat and channel where the message id is known.
+func (p *Postgres) GetDeliveries(postID int64, messengerType MessengerType) ([]Delivery, error) {
+	query := p.psql.Select(
+		"DISTINCT ON (chat_id, target) post_id",
+		"messenger", "chat_id", "target", "status", "error", "message_id", "message_ids", "caption", "updated_date",
+	).
+		From("deliveries").
+		Where(sq.Eq{
+			"post_id":   postID,
+			"messenger": messengerType,
+			"status":    []string{DeliverySent, DeliveryEdited},
+		}).
+		Where(sq.Gt{"message_id": 0}).
+		OrderBy("chat_id", "target", "id DESC")
+
+	rows, err := query.RunWith(p.db).Query()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []Delivery
+	for rows.Next() {
+		var (
+			d       Delivery
+			updated sql.NullTime
+		)
+		if err := rows.Scan(&d.PostID, &d.Messenger, &d.ChatID, &d.Target, &d.Status, &d.Error, &d.MessageID, pq.Array(&d.MessageIDs), &d.Caption, &updated); err != nil {
+			return nil, fmt.Errorf("failed to scan delivery: %w", err)
+		}
+		d.UpdatedDate = updated.Time
+		d.MessageIDs = messageIDs(d)
+		deliveries = append(deliveries, d)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating over deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// messageIDs returns the ids of every message of the delivery. Deliveries
+// saved with only the first id have just that one.
+func messageIDs(d Delivery) []int64 {
+	if len(d.MessageIDs) > 0 || d.MessageID == 0 {
+		return d.MessageIDs
+	}
+
+	return []int64{d.MessageID}
+}
+
+// RetractDeliveries marks the deliveries of a post to the chats as
+// retracted, so they are no longer edited or deleted.
+func (p *Postgres) RetractDeliveries(postID int64, messengerType MessengerType, chatIDs []string) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+
+	query := p.psql.Update("deliveries").
+		Set("status", DeliveryRetracted).
+		Where(sq.Eq{
+			"post_id":   postID,
+			"messenger": messengerType,
+			"chat_id":   chatIDs,
+			"status":    []string{DeliverySent, DeliveryEdited},
+		})
+
+	_, err := query.RunWith(p.db).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to retract deliveries: %w", err)
+	}
+
+	return nil
+}
+
+// GetLanguage returns the language chosen for a chat of the messenger or an
+// empty string if none was chosen.
+func (p *Postgres) GetLanguage(chatID string, messengerType MessengerType) (string, error) {
+	query := p.psql.Select("lang").
+		From("chat_languages").
+		Where(sq.Eq{"chat_id": chatID, "messenger": messengerType}).
+		Limit(1)
+
+	var lang string
+	err := query.RunWith(p.db).QueryRow().Scan(&lang)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get chat language: %w", err)
+	}
+
+	return lang, nil
+}
+
+func (p *Postgres) SetLanguage(chatID string, messengerType MessengerType, lang string) error {
+	query := p.psql.Insert("chat_languages").
+		Columns("chat_id", "messenger", "lang", "updated_at").
+		Values(chatID, messengerType, lang, sq.Expr("NOW()")).
+		Suffix("ON CONFLICT (chat_id, messenger) DO UPDATE SET lang = EXCLUDED.lang, updated_at = EXCLUDED.updated_at")
+
+	_, err := query.RunWith(p.db).Exec()
+	if err != nil {
+		return fmt.Errorf("failed to set chat language: %w", err)
+	}
+
+	return nil
+}
+
 func (p *Postgres) Close() error {
 	return p.db.Close()
 }
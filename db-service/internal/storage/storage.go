@@ -1,13 +1,39 @@
 package storage
 
 type Storage interface {
-	Save(chatID string, messengerType MessengerType) error
+	Save(chatID string, messengerType MessengerType, threadID int) error
 
 	Delete(chatID string) error
 
 	Exists(chatID string) (bool, error)
 
+	Migrate(oldChatID string, newChatID string) error
+
 	GetAllByMessenger(messengerType MessengerType) ([]int, error)
 
+	GetThreads(messengerType MessengerType) ([]ChatThread, error)
+
+	SetActive(chatID string, active bool) error
+
+	Stats() ([]ChatStats, error)
+
+	GetState(key string) (string, error)
+
+	SetState(key string, value string) error
+
+	MuteSource(chatID string, source string) error
+
+	GetMutedChats(source string) ([]int, error)
+
+	SaveDeliveries(deliveries []Delivery) error
+
+	GetDeliveries(postID int64, messengerType MessengerType) ([]Delivery, error)
+
+	RetractDeliveries(postID int64, messengerType MessengerType, chatIDs []string) error
+
+	GetLanguage(chatID string, messengerType MessengerType) (string, error)
+
+	SetLanguage(chatID string, messengerType MessengerType, lang string) error
+
 	Close() error
 }
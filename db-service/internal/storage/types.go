@@ -1,5 +1,7 @@
 package storage
 
+import "time"
+
 type MessengerType string
 
 const (
@@ -7,6 +9,56 @@ const (
 	VK       MessengerType = "Vk"
 )
 
+// Delivery targets.
+const (
+	TargetChat    = "chat"
+	TargetChannel = "channel"
+)
+
+// Delivery statuses.
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+	// DeliveryEdited marks a delivered message edited to an updated post.
+	DeliveryEdited = "edited"
+	// DeliveryRetracted marks a delivered message deleted after the post
+	// was pulled.
+	DeliveryRetracted = "retracted"
+)
+
+// Delivery is a log entry of a post sent to a chat or a channel.
+type Delivery struct {
+	PostID    int64         `json:"post_id"`
+	Messenger MessengerType `json:"messenger"`
+	ChatID    string        `json:"chat_id"`
+	Target    string        `json:"target"`
+	Status    string        `json:"status"`
+	Error     string        `json:"error,omitempty"`
+	// MessageID is the id of the message the post was delivered as, zero
+	// if unknown.
+	MessageID int64 `json:"message_id,omitempty"`
+	// MessageIDs are the ids of every message the post was delivered as
+	// when it is split into several, starting with MessageID.
+	MessageIDs []int64 `json:"message_ids,omitempty"`
+	// Caption reports that the post is the caption of a photo.
+	Caption bool `json:"caption,omitempty"`
+	// UpdatedDate is the update date of the delivered version of the post.
+	UpdatedDate time.Time `json:"updated_date"`
+}
+
+// ChatStats counts the chats of a messenger.
+type ChatStats struct {
+	Messenger MessengerType `json:"messenger"`
+	Active    int           `json:"active"`
+	Inactive  int           `json:"inactive"`
+}
+
+// ChatThread is the forum topic a chat is subscribed in.
+type ChatThread struct {
+	ID       string `json:"id"`
+	ThreadID int    `json:"thread_id"`
+}
+
 type ChatEntry struct {
 	ID        string        `json:"id"`
 	Messenger MessengerType `json:"messenger"`
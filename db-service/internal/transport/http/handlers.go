@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+	"strconv"
 
 	"db/internal/service"
 	"db/internal/storage"
@@ -25,7 +26,20 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
 	router.HandleFunc("/api/saveChat", h.SaveChat).Methods("POST")
 	router.HandleFunc("/api/deleteChat/{id}", h.DeleteChat).Methods("DELETE")
 	router.HandleFunc("/api/chatExist/{id}", h.ChatExists).Methods("GET")
+	router.HandleFunc("/api/migrateChat", h.MigrateChat).Methods("PUT")
 	router.HandleFunc("/api/allChats/{messenger}", h.GetChatsByMessenger).Methods("GET")
+	router.HandleFunc("/api/chatThreads/{messenger}", h.GetThreads).Methods("GET")
+	router.HandleFunc("/api/chatActive/{id}", h.SetChatActive).Methods("PUT")
+	router.HandleFunc("/api/stats", h.Stats).Methods("GET")
+	router.HandleFunc("/api/state/{key}", h.GetState).Methods("GET")
+	router.HandleFunc("/api/state/{key}", h.SetState).Methods("PUT")
+	router.HandleFunc("/api/muteSource", h.MuteSource).Methods("POST")
+	router.HandleFunc("/api/mutedChats/{source}", h.GetMutedChats).Methods("GET")
+	router.HandleFunc("/api/deliveries", h.SaveDeliveries).Methods("POST")
+	router.HandleFunc("/api/deliveries/{messenger}/{post}", h.GetDeliveries).Methods("GET")
+	router.HandleFunc("/api/retractDeliveries", h.RetractDeliveries).Methods("PUT")
+	router.HandleFunc("/api/language/{messenger}/{id}", h.GetLanguage).Methods("GET")
+	router.HandleFunc("/api/language/{messenger}/{id}", h.SetLanguage).Methods("PUT")
 }
 
 func (h *Handler) SaveChat(w http.ResponseWriter, r *http.Request) {
@@ -48,7 +62,7 @@ func (h *Handler) SaveChat(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.chatService.SaveChat(req.ID, messengerType); err != nil {
+	if err := h.chatService.SaveChat(req.ID, messengerType, req.ThreadID); err != nil {
 		h.respondWithError(w, http.StatusInternalServerError, err.Error())
 		log.Println("Error while chat saving")
 		return
@@ -74,6 +88,24 @@ func (h *Handler) DeleteChat(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) MigrateChat(w http.ResponseWriter, r *http.Request) {
+	var req MigrateChatRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.chatService.MigrateChat(req.OldID, req.NewID); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
 func (h *Handler) ChatExists(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	chatID := vars["id"]
@@ -117,6 +149,302 @@ func (h *Handler) GetChatsByMessenger(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+func (h *Handler) GetThreads(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var messengerType storage.MessengerType
+	switch vars["messenger"] {
+	case string(storage.Telegram):
+		messengerType = storage.Telegram
+	case string(storage.VK):
+		messengerType = storage.VK
+	default:
+		h.respondWithError(w, http.StatusBadRequest, "Invalid messenger type")
+		return
+	}
+
+	threads, err := h.chatService.GetThreads(messengerType)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+		Data:    threads,
+	})
+}
+
+func (h *Handler) SetChatActive(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chatID := vars["id"]
+
+	var req SetChatActiveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.chatService.SetChatActive(chatID, req.Active); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
+func (h *Handler) Stats(w http.ResponseWriter, r *http.Request) {
+	stats, err := h.chatService.Stats()
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+		Data:    stats,
+	})
+}
+
+func (h *Handler) GetState(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	value, err := h.chatService.GetState(key)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+		Data:    value,
+	})
+}
+
+func (h *Handler) SetState(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	key := vars["key"]
+
+	var req SetStateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.chatService.SetState(key, req.Value); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
+func (h *Handler) MuteSource(w http.ResponseWriter, r *http.Request) {
+	var req MuteSourceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.chatService.MuteSource(req.ID, req.Source); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
+func (h *Handler) GetMutedChats(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	source := vars["source"]
+
+	chatIDs, err := h.chatService.GetMutedChats(source)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+		Data:    chatIDs,
+	})
+}
+
+func (h *Handler) SaveDeliveries(w http.ResponseWriter, r *http.Request) {
+	var req []DeliveryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	deliveries := make([]storage.Delivery, 0, len(req))
+	for _, d := range req {
+		var messengerType storage.MessengerType
+		switch d.Messenger {
+		case string(storage.Telegram):
+			messengerType = storage.Telegram
+		case string(storage.VK):
+			messengerType = storage.VK
+		default:
+			h.respondWithError(w, http.StatusBadRequest, "Invalid messenger type")
+			return
+		}
+
+		deliveries = append(deliveries, storage.Delivery{
+			PostID:      d.PostID,
+			Messenger:   messengerType,
+			ChatID:      d.ChatID,
+			Target:      d.Target,
+			Status:      d.Status,
+			Error:       d.Error,
+			MessageID:   d.MessageID,
+			MessageIDs:  d.MessageIDs,
+			Caption:     d.Caption,
+			UpdatedDate: d.UpdatedDate,
+		})
+	}
+
+	if err := h.chatService.SaveDeliveries(deliveries); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var messengerType storage.MessengerType
+	switch vars["messenger"] {
+	case string(storage.Telegram):
+		messengerType = storage.Telegram
+	case string(storage.VK):
+		messengerType = storage.VK
+	default:
+		h.respondWithError(w, http.StatusBadRequest, "Invalid messenger type")
+		return
+	}
+
+	postID, err := strconv.ParseInt(vars["post"], 10, 64)
+	if err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid post ID")
+		return
+	}
+
+	deliveries, err := h.chatService.GetDeliveries(postID, messengerType)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+		Data:    deliveries,
+	})
+}
+
+func (h *Handler) RetractDeliveries(w http.ResponseWriter, r *http.Request) {
+	var req RetractDeliveriesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	var messengerType storage.MessengerType
+	switch req.Messenger {
+	case string(storage.Telegram):
+		messengerType = storage.Telegram
+	case string(storage.VK):
+		messengerType = storage.VK
+	default:
+		h.respondWithError(w, http.StatusBadRequest, "Invalid messenger type")
+		return
+	}
+
+	if err := h.chatService.RetractDeliveries(req.PostID, messengerType, req.ChatIDs); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
+func (h *Handler) GetLanguage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chatID := vars["id"]
+
+	var messengerType storage.MessengerType
+	switch vars["messenger"] {
+	case string(storage.Telegram):
+		messengerType = storage.Telegram
+	case string(storage.VK):
+		messengerType = storage.VK
+	default:
+		h.respondWithError(w, http.StatusBadRequest, "Invalid messenger type")
+		return
+	}
+
+	lang, err := h.chatService.GetLanguage(chatID, messengerType)
+	if err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+		Data:    lang,
+	})
+}
+
+func (h *Handler) SetLanguage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	chatID := vars["id"]
+
+	var messengerType storage.MessengerType
+	switch vars["messenger"] {
+	case string(storage.Telegram):
+		messengerType = storage.Telegram
+	case string(storage.VK):
+		messengerType = storage.VK
+	default:
+		h.respondWithError(w, http.StatusBadRequest, "Invalid messenger type")
+		return
+	}
+
+	var req SetLanguageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondWithError(w, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	defer r.Body.Close()
+
+	if err := h.chatService.SetLanguage(chatID, messengerType, req.Lang); err != nil {
+		h.respondWithError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	h.respondWithJSON(w, http.StatusOK, response{
+		Success: true,
+	})
+}
+
 func (h *Handler) respondWithError(w http.ResponseWriter, code int, message string) {
 	h.respondWithJSON(w, code, response{
 		Success: false,
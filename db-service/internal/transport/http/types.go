@@ -1,8 +1,54 @@
 package http
 
+import "time"
+
 type SaveChatRequest struct {
 	ID        string `json:"id"`
 	Messenger string `json:"messenger"`
+	// ThreadID is the forum topic to send posts to, zero for the chat itself.
+	ThreadID int `json:"thread_id,omitempty"`
+}
+
+type MigrateChatRequest struct {
+	OldID string `json:"old_id"`
+	NewID string `json:"new_id"`
+}
+
+type SetChatActiveRequest struct {
+	Active bool `json:"active"`
+}
+
+type SetStateRequest struct {
+	Value string `json:"value"`
+}
+
+type MuteSourceRequest struct {
+	ID     string `json:"id"`
+	Source string `json:"source"`
+}
+
+type DeliveryRequest struct {
+	PostID    int64  `json:"post_id"`
+	Messenger string `json:"messenger"`
+	ChatID    string `json:"chat_id"`
+	Target    string `json:"target"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+
+	MessageID   int64     `json:"message_id,omitempty"`
+	MessageIDs  []int64   `json:"message_ids,omitempty"`
+	Caption     bool      `json:"caption,omitempty"`
+	UpdatedDate time.Time `json:"updated_date"`
+}
+
+type RetractDeliveriesRequest struct {
+	PostID    int64    `json:"post_id"`
+	Messenger string   `json:"messenger"`
+	ChatIDs   []string `json:"chat_ids"`
+}
+
+type SetLanguageRequest struct {
+	Lang string `json:"lang"`
 }
 
 type response struct {
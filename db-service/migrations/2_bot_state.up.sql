@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS bot_state (
+	key VARCHAR(255) PRIMARY KEY,
+	value TEXT NOT NULL,
+	updated_at TIMESTAMP DEFAULT NOW()
+);
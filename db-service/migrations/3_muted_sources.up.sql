@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS muted_sources (
+	chat_id VARCHAR(255) NOT NULL,
+	source VARCHAR(255) NOT NULL,
+	created_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY (chat_id, source)
+);
+CREATE INDEX IF NOT EXISTS idx_muted_sources_source ON muted_sources(source);
@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS deliveries (
+	id BIGSERIAL PRIMARY KEY,
+	post_id BIGINT NOT NULL,
+	messenger VARCHAR(50) NOT NULL,
+	chat_id VARCHAR(255) NOT NULL,
+	target VARCHAR(16) NOT NULL,
+	status VARCHAR(16) NOT NULL,
+	error TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_deliveries_post ON deliveries(post_id, messenger);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS chat_languages
\ No newline at end of file
@@ -0,0 +1,7 @@
+CREATE TABLE IF NOT EXISTS chat_languages (
+	chat_id VARCHAR(255) NOT NULL,
+	messenger VARCHAR(50) NOT NULL,
+	lang VARCHAR(8) NOT NULL,
+	updated_at TIMESTAMP DEFAULT NOW(),
+	PRIMARY KEY (chat_id, messenger)
+);
@@ -0,0 +1,2 @@
+ALTER TABLE chat_entries DROP COLUMN IF EXISTS deactivated_at;
+ALTER TABLE chat_entries DROP COLUMN IF EXISTS active;
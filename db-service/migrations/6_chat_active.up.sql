@@ -0,0 +1,2 @@
+ALTER TABLE chat_entries ADD COLUMN IF NOT EXISTS active BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE chat_entries ADD COLUMN IF NOT EXISTS deactivated_at TIMESTAMP;
@@ -0,0 +1,4 @@
+ALTER TABLE deliveries DROP COLUMN IF EXISTS updated_date;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS caption;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS message_ids;
+ALTER TABLE deliveries DROP COLUMN IF EXISTS message_id;
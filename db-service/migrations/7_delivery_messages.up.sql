@@ -0,0 +1,4 @@
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS message_id BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS message_ids BIGINT[] NOT NULL DEFAULT '{}';
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS caption BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE deliveries ADD COLUMN IF NOT EXISTS updated_date TIMESTAMPTZ;
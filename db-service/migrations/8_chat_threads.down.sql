@@ -0,0 +1 @@
+ALTER TABLE chat_entries DROP COLUMN IF EXISTS thread_id;
@@ -0,0 +1 @@
+ALTER TABLE chat_entries ADD COLUMN IF NOT EXISTS thread_id BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,91 @@
+// Package i18n is the message catalog shared by the Telegram and VK bots.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Lang is a language the bots can speak.
+type Lang string
+
+const (
+	English Lang = "en"
+	Russian Lang = "ru"
+)
+
+// Default is used when the language of a chat is unknown, most of our
+// readers speak Russian.
+const Default = Russian
+
+// Languages lists every language of the catalog.
+var Languages = []Lang{English, Russian}
+
+// russianSpeaking are the languages whose speakers usually read Russian
+// better than English.
+var russianSpeaking = []string{"ru", "uk", "be", "kk", "ky", "uz", "tg", "hy", "az"}
+
+// Parse returns the catalog language for a language code such as "ru" or
+// "en-US". ok is false if the code is empty.
+func Parse(code string) (lang Lang, ok bool) {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if code == "" {
+		return Default, false
+	}
+
+	base, _, _ := strings.Cut(code, "-")
+	for _, l := range russianSpeaking {
+		if base == l {
+			return Russian, true
+		}
+	}
+
+	return English, true
+}
+
+// Supported reports whether code is exactly one of the catalog languages, as
+// expected from /lang.
+func Supported(code string) (Lang, bool) {
+	for _, l := range Languages {
+		if strings.EqualFold(code, string(l)) {
+			return l, true
+		}
+	}
+
+	return "", false
+}
+
+// T returns the message for key in lang formatted with args. Messages missing
+// in lang fall back to English.
+func T(lang Lang, key Key, args ...any) string {
+	msg, ok := lookup(messages[key], lang)
+	if !ok {
+		return string(key)
+	}
+
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
+
+// N is T for messages that depend on a number: the form is chosen by n using
+// the plural rules of lang.
+func N(lang Lang, key Key, n int, args ...any) string {
+	forms, ok := lookup(plurals[key], lang)
+	if !ok {
+		return string(key)
+	}
+
+	return fmt.Sprintf(forms.pick(lang, n), args...)
+}
+
+func lookup[T any](translations map[Lang]T, lang Lang) (T, bool) {
+	if msg, ok := translations[lang]; ok {
+		return msg, true
+	}
+
+	msg, ok := translations[English]
+	return msg, ok
+}
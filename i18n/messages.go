@@ -0,0 +1,255 @@
+package i18n
+
+// Key identifies a message of the catalog.
+type Key string
+
+const (
+	Hello               Key = "hello"
+	HelpIntro           Key = "help_intro"
+	UnknownCommand      Key = "unknown_command"
+	NoPosts             Key = "no_posts"
+	AlreadySubscribed   Key = "already_subscribed"
+	Subscribed          Key = "subscribed"
+	AlreadyUnsubscribed Key = "already_unsubscribed"
+	Unsubscribed        Key = "unsubscribed"
+	SourceMuted         Key = "source_muted"
+	AdminsOnly          Key = "admins_only"
+	LatestHeader        Key = "latest_header"
+	LatestUsage         Key = "latest_usage"
+	ReadPost            Key = "read_post"
+	LangUsage           Key = "lang_usage"
+	LangChanged         Key = "lang_changed"
+	StatsHeader         Key = "stats_header"
+	StatsLine           Key = "stats_line"
+	BroadcastUsage      Key = "broadcast_usage"
+	BroadcastConfirm    Key = "broadcast_confirm"
+	BroadcastNothing    Key = "broadcast_nothing"
+	BroadcastCancelled  Key = "broadcast_cancelled"
+	BroadcastStarted    Key = "broadcast_started"
+	BroadcastRunning    Key = "broadcast_running"
+	BroadcastProgress   Key = "broadcast_progress"
+	BroadcastDone       Key = "broadcast_done"
+	BroadcastStopped    Key = "broadcast_stopped"
+	PreviewQueued       Key = "preview_queued"
+	PreviewLatest       Key = "preview_latest"
+
+	BtnSubscribe   Key = "btn_subscribe"
+	BtnUnsubscribe Key = "btn_unsubscribe"
+	BtnOpenPost    Key = "btn_open_post"
+	BtnMuteSource  Key = "btn_mute_source"
+	BtnMore        Key = "btn_more"
+	BtnSend        Key = "btn_send"
+	BtnCancel      Key = "btn_cancel"
+
+	CmdStart       Key = "cmd_start"
+	CmdHelp        Key = "cmd_help"
+	CmdLatest      Key = "cmd_latest"
+	CmdSubscribe   Key = "cmd_subscribe"
+	CmdUnsubscribe Key = "cmd_unsubscribe"
+	CmdLang        Key = "cmd_lang"
+	CmdStats       Key = "cmd_stats"
+	CmdBroadcast   Key = "cmd_broadcast"
+	CmdPreview     Key = "cmd_preview"
+)
+
+var messages = map[Key]map[Lang]string{
+	Hello: {
+		English: "Hi there! 👾\n\n",
+		Russian: "Привет! 👾\n\n",
+	},
+	HelpIntro: {
+		English: "I can send u latest post from blogAtor\ncommands:",
+		Russian: "Я присылаю свежие посты из blogAtor\nкоманды:",
+	},
+	UnknownCommand: {
+		English: "Unknown command 🤔",
+		Russian: "Неизвестная команда 🤔",
+	},
+	NoPosts: {
+		English: "No posts found 🤷‍♂️",
+		Russian: "Постов не нашлось 🤷‍♂️",
+	},
+	AlreadySubscribed: {
+		English: "You are already subscribed to the blog",
+		Russian: "Вы уже подписаны на блог",
+	},
+	Subscribed: {
+		English: "You are subscribed to the blog",
+		Russian: "Вы подписались на блог",
+	},
+	AlreadyUnsubscribed: {
+		English: "You are already unsubscribed!",
+		Russian: "Вы уже отписаны!",
+	},
+	Unsubscribed: {
+		English: "You are unsubscribed from the blog",
+		Russian: "Вы отписались от блога",
+	},
+	SourceMuted: {
+		English: "You will no longer get posts from %s",
+		Russian: "Посты с %s больше не будут приходить",
+	},
+	AdminsOnly: {
+		English: "Only chat administrators can do this",
+		Russian: "Это могут сделать только администраторы чата",
+	},
+	LatestUsage: {
+		English: "Usage: /latest [count] [days], e.g. /latest 10 2",
+		Russian: "Использование: /latest [кол-во] [дней], например /latest 10 2",
+	},
+	ReadPost: {
+		English: "Read the post",
+		Russian: "Читать пост",
+	},
+	LangUsage: {
+		English: "Usage: /lang en|ru, the current language is %s",
+		Russian: "Использование: /lang en|ru, текущий язык: %s",
+	},
+	LangChanged: {
+		English: "I will speak English in this chat",
+		Russian: "Теперь в этом чате я говорю по-русски",
+	},
+	StatsHeader: {
+		English: "📊 Subscribers:",
+		Russian: "📊 Подписчики:",
+	},
+	StatsLine: {
+		English: "%s: %d active, %d inactive",
+		Russian: "%s: активных %d, неактивных %d",
+	},
+	BroadcastUsage: {
+		English: "Usage: /broadcast <text>",
+		Russian: "Использование: /broadcast <текст>",
+	},
+	BroadcastConfirm: {
+		English: "Send this message to all subscribers?\n\n%s",
+		Russian: "Отправить это сообщение всем подписчикам?\n\n%s",
+	},
+	BroadcastNothing: {
+		English: "There is no broadcast to send",
+		Russian: "Нет рассылки для отправки",
+	},
+	BroadcastCancelled: {
+		English: "Broadcast cancelled",
+		Russian: "Рассылка отменена",
+	},
+	BroadcastStarted: {
+		English: "Sending the broadcast…",
+		Russian: "Отправляю рассылку…",
+	},
+	BroadcastRunning: {
+		English: "Another broadcast is still being sent, try again when it is done",
+		Russian: "Предыдущая рассылка ещё отправляется, повторите, когда она закончится",
+	},
+	BroadcastProgress: {
+		English: "📣 Sending the broadcast: %d of %d chats done",
+		Russian: "📣 Отправляю рассылку: обработано чатов %d из %d",
+	},
+	BroadcastStopped: {
+		English: "⚠️ The broadcast was interrupted: delivered to %d of %d chats",
+		Russian: "⚠️ Рассылка прервана: доставлено в чаты %d из %d",
+	},
+	PreviewQueued: {
+		English: "👀 The next post in the queue:",
+		Russian: "👀 Следующий пост в очереди:",
+	},
+	PreviewLatest: {
+		English: "👀 The queue is empty, this is the latest post:",
+		Russian: "👀 Очередь пуста, вот последний пост:",
+	},
+
+	BtnSubscribe: {
+		English: "Subscribe",
+		Russian: "Подписаться",
+	},
+	BtnUnsubscribe: {
+		English: "Unsubscribe",
+		Russian: "Отписаться",
+	},
+	BtnOpenPost: {
+		English: "Open post",
+		Russian: "Открыть пост",
+	},
+	BtnMuteSource: {
+		English: "Mute this source",
+		Russian: "Скрыть этот источник",
+	},
+	BtnMore: {
+		English: "More ▶",
+		Russian: "Ещё ▶",
+	},
+	BtnSend: {
+		English: "Send",
+		Russian: "Отправить",
+	},
+	BtnCancel: {
+		English: "Cancel",
+		Russian: "Отменить",
+	},
+
+	CmdStart: {
+		English: "Start the bot",
+		Russian: "Запустить бота",
+	},
+	CmdHelp: {
+		English: "Show the list of commands",
+		Russian: "Показать список команд",
+	},
+	CmdLatest: {
+		English: "Latest posts: /latest [count] [days]",
+		Russian: "Последние посты: /latest [кол-во] [дней]",
+	},
+	CmdSubscribe: {
+		English: "Subscribe to new posts from blogator",
+		Russian: "Подписаться на новые посты blogator",
+	},
+	CmdUnsubscribe: {
+		English: "Unsubscribe from new posts",
+		Russian: "Отписаться от новых постов",
+	},
+	CmdLang: {
+		English: "Change the language: /lang en|ru",
+		Russian: "Сменить язык: /lang en|ru",
+	},
+	CmdStats: {
+		English: "Show subscriber counts",
+		Russian: "Показать число подписчиков",
+	},
+	CmdBroadcast: {
+		English: "Send a message to all subscribers: /broadcast <text>",
+		Russian: "Написать всем подписчикам: /broadcast <текст>",
+	},
+	CmdPreview: {
+		English: "Preview the next post",
+		Russian: "Посмотреть следующий пост",
+	},
+}
+
+// plurals are the messages formatted with N. The arguments of LatestHeader
+// are the first and the last post of the page, the number of posts and the
+// number of days; of BroadcastDone the number of chats the message was
+// delivered to and the number of subscribed chats.
+var plurals = map[Key]map[Lang]Forms{
+	LatestHeader: {
+		English: {
+			One:   "📰 Latest posts %[1]d–%[2]d of %[3]d for the last day:",
+			Other: "📰 Latest posts %[1]d–%[2]d of %[3]d for the last %[4]d days:",
+		},
+		Russian: {
+			One:   "📰 Последние посты %[1]d–%[2]d из %[3]d за последний %[4]d день:",
+			Few:   "📰 Последние посты %[1]d–%[2]d из %[3]d за последние %[4]d дня:",
+			Other: "📰 Последние посты %[1]d–%[2]d из %[3]d за последние %[4]d дней:",
+		},
+	},
+	BroadcastDone: {
+		English: {
+			One:   "📣 The broadcast was delivered to %[1]d of %[2]d chat",
+			Other: "📣 The broadcast was delivered to %[1]d of %[2]d chats",
+		},
+		Russian: {
+			One:   "📣 Рассылка доставлена в %[1]d из %[2]d чата",
+			Few:   "📣 Рассылка доставлена в %[1]d из %[2]d чатов",
+			Other: "📣 Рассылка доставлена в %[1]d из %[2]d чатов",
+		},
+	},
+}
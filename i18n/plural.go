@@ -0,0 +1,32 @@
+package i18n
+
+// Forms holds the plural forms of a message. English uses only One and
+// Other, Russian also needs Few: 1 пост, 2 поста, 5 постов.
+type Forms struct {
+	One   string
+	Few   string
+	Other string
+}
+
+func (f Forms) pick(lang Lang, n int) string {
+	if n < 0 {
+		n = -n
+	}
+
+	switch lang {
+	case Russian:
+		switch mod10, mod100 := n%10, n%100; {
+		case mod10 == 1 && mod100 != 11:
+			return f.One
+		case mod10 >= 2 && mod10 <= 4 && (mod100 < 12 || mod100 > 14):
+			return f.Few
+		default:
+			return f.Other
+		}
+	default:
+		if n == 1 {
+			return f.One
+		}
+		return f.Other
+	}
+}
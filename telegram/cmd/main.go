@@ -8,8 +8,11 @@ import (
 	"api/internal/config"
 	event_consumer "api/internal/consumer/event-consumer"
 	"api/internal/events/telegram"
+	"api/internal/logger"
 	"context"
+	_ "expvar"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -25,11 +28,21 @@ func main() {
 
 	cfg := config.MustLoad()
 
-	eventProccessor := telegram.New(
-		tgClient.New(cfg.TgHost, cfg.TgToken),
-		blogator.New(cfg.AtorToken),
-		db.New(cfg.DbHost, cfg.DbPort),
-	)
+	logger.Setup(cfg.LogLevel, cfg.Secrets()...)
+
+	tgOpts := []tgClient.Option{tgClient.WithHTTPClient(tgClient.NewHTTPClient(cfg.TgTimeout, cfg.TgProxy))}
+	if cfg.TgLocalAPI {
+		tgOpts = append(tgOpts, tgClient.WithLocalServer())
+	}
+
+	if cfg.MetricsAddr != "" {
+		go func() {
+			log.Printf("Serving metrics on %s/debug/vars", cfg.MetricsAddr)
+			if err := http.ListenAndServe(cfg.MetricsAddr, nil); err != nil {
+				log.Printf("Metrics server stopped: %v", err)
+			}
+		}()
+	}
 
 	rmq, err := rabbitmq.New(cfg.RabbitUrl, cfg.RabbitQueue)
 	if err != nil {
@@ -37,17 +50,36 @@ func main() {
 	}
 	defer rmq.Close()
 
+	eventProccessor := telegram.New(
+		tgClient.New(cfg.TgAPIURL, cfg.TgToken, tgOpts...),
+		blogator.New(cfg.AtorToken),
+		db.New(cfg.DbHost, cfg.DbPort),
+		rmq,
+		cfg.Channels,
+		cfg.Admins,
+	)
+
+	if err := eventProccessor.RegisterCommands(ctx); err != nil {
+		log.Printf("Failed to register bot commands: %v", err)
+	}
+
 	err = rmq.Consume(ctx, func(post rabbitmq.Response) error {
+		if post.Type == rabbitmq.PostDeleted {
+			return eventProccessor.RetractPost(ctx, post.PostID)
+		}
 		return eventProccessor.SendPostToSubscribers(ctx, post)
 	})
 	if err != nil {
 		log.Fatalf("Failed to set up RabbitMQ consumer: %v", err)
 	}
 
-	consumer := event_consumer.New(eventProccessor, eventProccessor, cfg.BatchSize)
+	consumer := event_consumer.New(eventProccessor, eventProccessor, cfg.BatchSize, cfg.Workers)
 
+	consumerDone := make(chan struct{})
 	go func() {
-		if err := consumer.Start(); err != nil {
+		defer close(consumerDone)
+
+		if err := consumer.Start(ctx); err != nil {
 			log.Printf("Telegram consumer stopped: %v", err)
 			cancel()
 		}
@@ -68,12 +100,11 @@ func main() {
 		log.Printf("Error closing RabbitMQ: %v", err)
 	}
 
+	log.Println("Waiting for in-flight updates...")
 	select {
+	case <-consumerDone:
+		log.Println("Shutdown complete")
 	case <-shutdownCtx.Done():
-		if shutdownCtx.Err() == context.DeadlineExceeded {
-			log.Println("Shutdown timed out, forcing exit")
-		} else {
-			log.Println("Shutdown complete")
-		}
+		log.Println("Shutdown timed out, forcing exit")
 	}
 }
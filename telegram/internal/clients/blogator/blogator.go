@@ -5,7 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"time"
 )
@@ -24,42 +24,42 @@ func New(baseURL string) *Client {
 
 func (c *Client) GetNewItems(ctx context.Context, since time.Time) ([]DataItem, error) {
 	url := fmt.Sprintf("http://%s?since=%s", c.baseURL, since.Format(time.RFC3339))
-	log.Printf("Making request to Blogator API: %s", url)
+	slog.Debug("Blogator request", "url", url)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		log.Printf("Error creating request: %v", err)
+		slog.Error("Can't create Blogator request", "error", err)
 		return nil, err
 	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		log.Printf("Error executing request: %v", err)
+		slog.Error("Blogator request failed", "error", err)
 		return nil, err
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading response body: %v", err)
+		slog.Error("Can't read Blogator response", "error", err)
 		return nil, err
 	}
 
-	log.Printf("Blogator API response status: %d", resp.StatusCode)
+	slog.Debug("Blogator response", "status", resp.StatusCode)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
 	var res Response
-	
+
 	if err := json.Unmarshal(body, &res); err != nil {
-		log.Printf("Error unmarshaling response: %v", err)
-		log.Printf("Raw response body: %s", string(body))
+		slog.Error("Can't unmarshal Blogator response", "error", err)
+		slog.Debug("Blogator response", "body", string(body))
 		return nil, fmt.Errorf("can't unmarshall: %w", err)
 	}
 
-	log.Printf("Successfully unmarshaled %d items", len(res.Data))
+	slog.Debug("Blogator items received", "count", len(res.Data))
 
 	return res.Data, nil
 }
@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
@@ -24,7 +24,17 @@ const (
 	methodSaveChat   = "saveChat"
 	methodDeleteChat = "deleteChat"
 	methodChatExist  = "chatExist"
+	methodMigrate    = "migrateChat"
 	methodAllChats   = "allChats"
+	methodThreads    = "chatThreads"
+	methodChatActive = "chatActive"
+	methodStats      = "stats"
+	methodState      = "state"
+	methodMute       = "muteSource"
+	methodMutedChats = "mutedChats"
+	methodDeliveries = "deliveries"
+	methodRetract    = "retractDeliveries"
+	methodLanguage   = "language"
 )
 
 func New(h string, b string) *Client {
@@ -35,7 +45,9 @@ func New(h string, b string) *Client {
 	}
 }
 
-func (c *Client) SaveUser(ctx context.Context, chatId int) error {
+// SaveUser subscribes the chat. A non-zero threadID sends the posts to that
+// forum topic.
+func (c *Client) SaveUser(ctx context.Context, chatId int, threadID int) error {
 	u := url.URL{
 		Scheme: "http",
 		Host:   c.Host,
@@ -45,6 +57,7 @@ func (c *Client) SaveUser(ctx context.Context, chatId int) error {
 	data := map[string]interface{}{
 		"id":        strconv.Itoa(chatId),
 		"messenger": messangerType,
+		"thread_id": threadID,
 	}
 
 	jsonData, err := json.Marshal(data)
@@ -119,6 +132,52 @@ func (c *Client) DeleteUser(ctx context.Context, chatId int) error {
 	return nil
 }
 
+func (c *Client) MigrateChat(ctx context.Context, oldChatId int, newChatId int) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodMigrate),
+	}
+
+	data := map[string]interface{}{
+		"old_id": strconv.Itoa(oldChatId),
+		"new_id": strconv.Itoa(newChatId),
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while migrating chatId %d", oldChatId)
+	}
+	return nil
+}
+
 func (c *Client) ChatExists(ctx context.Context, chatId int) (bool, error) {
 	u := url.URL{
 		Scheme: "http",
@@ -145,7 +204,7 @@ func (c *Client) ChatExists(ctx context.Context, chatId int) (bool, error) {
 	if err := json.Unmarshal(body, &success); err != nil {
 		return false, fmt.Errorf("can`t unmurshall json: %v", err)
 	}
-	log.Println(success)
+	slog.Debug("db-service response", "method", methodChatExist, "data", success.Data)
 	if !success.Success {
 		return false, fmt.Errorf("API returned non-success response")
 	}
@@ -158,7 +217,7 @@ func (c *Client) AllUsers(ctx context.Context, messangerType string) ([]int, err
 		Host:   c.Host,
 		Path:   path.Join(c.BasePath, methodAllChats, messangerType),
 	}
-	log.Println("making request to: ", u.String())
+	slog.Debug("db-service request", "url", u.String())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("can`t make req: %v", err)
@@ -185,3 +244,491 @@ func (c *Client) AllUsers(ctx context.Context, messangerType string) ([]int, err
 	}
 	return res.Data, nil
 }
+
+// Threads returns the forum topics of the chats subscribed in one, keyed by
+// chat id.
+func (c *Client) Threads(ctx context.Context, messangerType string) (map[int]int, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodThreads, messangerType),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res ThreadsResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("error while getting chat threads")
+	}
+
+	threads := make(map[int]int, len(res.Data))
+	for _, t := range res.Data {
+		chatID, err := strconv.Atoi(t.ID)
+		if err != nil {
+			continue
+		}
+		threads[chatID] = t.ThreadID
+	}
+
+	return threads, nil
+}
+
+func (c *Client) GetState(ctx context.Context, key string) (string, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodState, key),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res StateResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return "", fmt.Errorf("error while getting state %s", key)
+	}
+	return res.Data, nil
+}
+
+func (c *Client) SetState(ctx context.Context, key string, value string) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodState, key),
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"value": value})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while saving state %s", key)
+	}
+	return nil
+}
+
+func (c *Client) MuteSource(ctx context.Context, chatId int, source string) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodMute),
+	}
+
+	data := map[string]interface{}{
+		"id":     strconv.Itoa(chatId),
+		"source": source,
+	}
+
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while muting source %s", source)
+	}
+	return nil
+}
+
+func (c *Client) MutedChats(ctx context.Context, source string) ([]int, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodMutedChats, source),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res Response
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("error while getting muted chats for %s", source)
+	}
+	return res.Data, nil
+}
+
+func (c *Client) SaveDeliveries(ctx context.Context, deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodDeliveries),
+	}
+
+	jsonData, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while saving deliveries")
+	}
+	return nil
+}
+
+// GetLanguage returns the language chosen for the chat with /lang or an empty
+// string if there is none.
+func (c *Client) GetLanguage(ctx context.Context, chatId int) (string, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodLanguage, messangerType, strconv.Itoa(chatId)),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res LanguageResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return "", fmt.Errorf("error while getting language of chat %d", chatId)
+	}
+	return res.Data, nil
+}
+
+func (c *Client) SetLanguage(ctx context.Context, chatId int, lang string) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodLanguage, messangerType, strconv.Itoa(chatId)),
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"lang": lang})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while saving language of chat %d", chatId)
+	}
+	return nil
+}
+
+// SetChatActive marks a subscribed chat as reachable or not. Inactive chats
+// are skipped by AllUsers.
+func (c *Client) SetChatActive(ctx context.Context, chatId int, active bool) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodChatActive, strconv.Itoa(chatId)),
+	}
+
+	jsonData, err := json.Marshal(map[string]bool{"active": active})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while updating chat %d", chatId)
+	}
+	return nil
+}
+
+// RetractDeliveries marks the deliveries of the post to the chats as
+// retracted.
+func (c *Client) RetractDeliveries(ctx context.Context, messangerType string, postID int64, chatIDs []string) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodRetract),
+	}
+
+	jsonData, err := json.Marshal(RetractRequest{
+		PostID:    postID,
+		Messenger: messangerType,
+		ChatIDs:   chatIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while retracting deliveries of post %d", postID)
+	}
+	return nil
+}
+
+// Deliveries returns the latest delivery of the post to every chat where
+// the message id is known.
+func (c *Client) Deliveries(ctx context.Context, messangerType string, postID int64) ([]Delivery, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodDeliveries, messangerType, strconv.FormatInt(postID, 10)),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res DeliveriesResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("error while getting deliveries of post %d", postID)
+	}
+	return res.Data, nil
+}
+
+// Stats returns the number of active and inactive chats of every messenger.
+func (c *Client) Stats(ctx context.Context) ([]ChatStats, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodStats),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res StatsResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("error while getting stats")
+	}
+	return res.Data, nil
+}
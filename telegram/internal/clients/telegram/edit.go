@@ -0,0 +1,131 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const (
+	editMessageTextMethod    = "editMessageText"
+	editMessageCaptionMethod = "editMessageCaption"
+	deleteMessageMethod      = "deleteMessage"
+)
+
+// DeleteMessage deletes a message sent by the bot. A message that is already
+// gone is not an error.
+func (c *Client) DeleteMessage(ctx context.Context, chatID int, messageID int) error {
+	q := url.Values{}
+	q.Add("chat_id", strconv.Itoa(chatID))
+	q.Add("message_id", strconv.Itoa(messageID))
+
+	_, err := c.send(ctx, chatID, deleteMessageMethod, q)
+	if err != nil && !errors.Is(err, ErrMessageNotFound) {
+		return fmt.Errorf("can't delete message: %w", err)
+	}
+
+	return nil
+}
+
+// EditMessageText replaces the text of the messages a text was sent as by
+// SendMessageWithIDs. The new text is split the same way and every part is
+// edited into the message at its place; parts beyond the messages are sent
+// after them and messages beyond the parts are deleted. The reply markup goes
+// to the last part. It returns the ids of the messages that hold the text
+// now, including the ones left as they were by an error. An edit that
+// changes nothing is not an error.
+func (c *Client) EditMessageText(ctx context.Context, chatID int, messageIDs []int, text string, opts ...SendOption) ([]int, error) {
+	parts := splitText(text, maxMessageLength, parseMode(opts))
+	if len(parts) == 0 {
+		parts = []string{text}
+	}
+
+	messageIDs, err := c.editParts(ctx, chatID, messageIDs, parts, opts)
+	if err != nil {
+		return messageIDs, fmt.Errorf("can't edit message: %w", err)
+	}
+
+	return messageIDs, nil
+}
+
+// EditMessageCaption replaces the caption of a photo sent by
+// SendPhotoWithIDs together with the messages the rest of the caption was
+// sent as. The rest of the new caption is edited into them like
+// EditMessageText does.
+func (c *Client) EditMessageCaption(ctx context.Context, chatID int, messageIDs []int, caption string, opts ...SendOption) ([]int, error) {
+	if len(messageIDs) == 0 {
+		return nil, errors.New("can't edit caption: no photo message")
+	}
+
+	caption, rest := cutText(caption, maxCaptionLength, parseMode(opts))
+
+	if err := c.edit(ctx, chatID, messageIDs[0], editMessageCaptionMethod, "caption", caption, rest != "", opts); err != nil {
+		return messageIDs, fmt.Errorf("can't edit caption: %w", err)
+	}
+
+	restIDs, err := c.editParts(ctx, chatID, messageIDs[1:], splitText(rest, maxMessageLength, parseMode(opts)), opts)
+	messageIDs = append([]int{messageIDs[0]}, restIDs...)
+	if err != nil {
+		return messageIDs, fmt.Errorf("can't edit the rest of the caption: %w", err)
+	}
+
+	return messageIDs, nil
+}
+
+// editParts edits the parts of a split text into the messages, sends the
+// parts there are no messages for and deletes the messages there are no
+// parts for. The ids returned are the messages that hold the text now.
+func (c *Client) editParts(ctx context.Context, chatID int, messageIDs []int, parts []string, opts []SendOption) ([]int, error) {
+	edited := make([]int, 0, len(parts))
+	for i, part := range parts {
+		last := i == len(parts)-1
+
+		if i >= len(messageIDs) {
+			messageID, err := c.sendPart(ctx, chatID, part, last, opts)
+			if err != nil {
+				return edited, err
+			}
+
+			edited = append(edited, messageID)
+			continue
+		}
+
+		if err := c.edit(ctx, chatID, messageIDs[i], editMessageTextMethod, "text", part, !last, opts); err != nil {
+			return append(edited, messageIDs[i:]...), err
+		}
+
+		edited = append(edited, messageIDs[i])
+	}
+
+	for i := len(parts); i < len(messageIDs); i++ {
+		if err := c.DeleteMessage(ctx, chatID, messageIDs[i]); err != nil {
+			return append(edited, messageIDs[i:]...), err
+		}
+	}
+
+	return edited, nil
+}
+
+func (c *Client) edit(ctx context.Context, chatID int, messageID int, method string, field string, text string, cut bool, opts []SendOption) error {
+	q := url.Values{}
+	q.Add("chat_id", strconv.Itoa(chatID))
+	q.Add("message_id", strconv.Itoa(messageID))
+	q.Add(field, text)
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if cut {
+		q.Del("reply_markup")
+	}
+
+	_, err := c.send(ctx, chatID, method, q)
+	if errors.Is(err, ErrNotModified) {
+		return nil
+	}
+
+	return err
+}
@@ -0,0 +1,85 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrChatUnavailable matches errors after which messages can never be
+// delivered to the chat: the bot was blocked or kicked, the user was
+// deleted or the chat doesn't exist.
+var ErrChatUnavailable = errors.New("chat is unavailable")
+
+// ErrMessageNotFound matches the error of deleting or editing a message that
+// no longer exists.
+var ErrMessageNotFound = errors.New("message not found")
+
+// ErrRemoteFile matches the error of sending a file by URL that Telegram
+// couldn't fetch, e.g. because it is too large to be sent by URL.
+var ErrRemoteFile = errors.New("can't fetch file by URL")
+
+// ErrNotModified matches the error of an edit that leaves the message as it
+// was.
+var ErrNotModified = errors.New("message is not modified")
+
+// APIError is an error returned by the Telegram Bot API.
+type APIError struct {
+	Code        int
+	Description string
+	// RetryAfter is the number of seconds to wait before repeating a request
+	// rejected with 429.
+	RetryAfter int
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("Telegram API error %d: %s", e.Code, e.Description)
+}
+
+// Is makes errors.Is(err, ErrChatUnavailable) report permanent errors,
+// errors.Is(err, ErrMessageNotFound) report missing messages,
+// errors.Is(err, ErrRemoteFile) report files Telegram couldn't fetch and
+// errors.Is(err, ErrNotModified) report edits that changed nothing.
+func (e *APIError) Is(target error) bool {
+	desc := strings.ToLower(e.Description)
+
+	switch target {
+	case ErrChatUnavailable:
+		return e.permanent()
+	case ErrMessageNotFound:
+		return e.Code == http.StatusBadRequest && (strings.Contains(desc, "message to delete not found") || strings.Contains(desc, "message to edit not found"))
+	case ErrRemoteFile:
+		return e.Code == http.StatusBadRequest && (strings.Contains(desc, "failed to get http url content") || strings.Contains(desc, "wrong file identifier/http url specified"))
+	case ErrNotModified:
+		return e.Code == http.StatusBadRequest && strings.Contains(desc, "message is not modified")
+	default:
+		return false
+	}
+}
+
+// permanentForbidden are the descriptions of 403 errors that don't pass.
+// Others, such as missing rights to send messages in a group, may be lifted.
+var permanentForbidden = []string{
+	"bot was blocked by the user",
+	"bot was kicked from",
+	"user is deactivated",
+}
+
+func (e *APIError) permanent() bool {
+	desc := strings.ToLower(e.Description)
+
+	switch e.Code {
+	case http.StatusForbidden:
+		for _, s := range permanentForbidden {
+			if strings.Contains(desc, s) {
+				return true
+			}
+		}
+		return false
+	case http.StatusBadRequest:
+		return strings.Contains(desc, "chat not found") || strings.Contains(desc, "user is deactivated")
+	default:
+		return false
+	}
+}
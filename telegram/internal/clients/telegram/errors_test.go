@@ -0,0 +1,31 @@
+package telegram
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestChatUnavailable(t *testing.T) {
+	tests := []struct {
+		code        int
+		description string
+		want        bool
+	}{
+		{403, "Forbidden: bot was blocked by the user", true},
+		{403, "Forbidden: bot was kicked from the supergroup chat", true},
+		{403, "Forbidden: user is deactivated", true},
+		{403, "Forbidden: not enough rights to send text messages to the chat", false},
+		{403, "Forbidden: bot can't initiate conversation with a user", false},
+		{400, "Bad Request: chat not found", true},
+		{400, "Bad Request: message is too long", false},
+		{429, "Too Many Requests: retry after 5", false},
+	}
+
+	for _, tt := range tests {
+		err := fmt.Errorf("can't send message: %w", &APIError{Code: tt.code, Description: tt.description})
+		if got := errors.Is(err, ErrChatUnavailable); got != tt.want {
+			t.Errorf("errors.Is(%d %q, ErrChatUnavailable) = %v, want %v", tt.code, tt.description, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package telegram
+
+import "strings"
+
+var htmlEscaper = strings.NewReplacer(
+	"&", "&amp;",
+	"<", "&lt;",
+	">", "&gt;",
+	`"`, "&quot;",
+)
+
+// EscapeHTML escapes text for messages sent with ParseModeHTML. The result is
+// safe both as element content and inside a quoted attribute such as href.
+func EscapeHTML(text string) string {
+	return htmlEscaper.Replace(text)
+}
+
+var markdownV2Escaper = strings.NewReplacer(
+	`\`, `\\`,
+	"_", `\_`,
+	"*", `\*`,
+	"[", `\[`,
+	"]", `\]`,
+	"(", `\(`,
+	")", `\)`,
+	"~", `\~`,
+	"`", "\\`",
+	">", `\>`,
+	"#", `\#`,
+	"+", `\+`,
+	"-", `\-`,
+	"=", `\=`,
+	"|", `\|`,
+	"{", `\{`,
+	"}", `\}`,
+	".", `\.`,
+	"!", `\!`,
+)
+
+// EscapeMarkdownV2 escapes text for messages sent with ParseModeMarkdownV2.
+func EscapeMarkdownV2(text string) string {
+	return markdownV2Escaper.Replace(text)
+}
+
+var markdownV2URLEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	")", `\)`,
+)
+
+// EscapeMarkdownV2URL escapes the URL part of a MarkdownV2 inline link.
+func EscapeMarkdownV2URL(link string) string {
+	return markdownV2URLEscaper.Replace(link)
+}
@@ -0,0 +1,96 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const answerInlineQueryMethod = "answerInlineQuery"
+
+// maxInlineResults is the number of results an inline query answer may
+// contain.
+const maxInlineResults = 50
+
+type InlineQueryResultArticle struct {
+	Type                string                  `json:"type"`
+	ID                  string                  `json:"id"`
+	Title               string                  `json:"title"`
+	Description         string                  `json:"description,omitempty"`
+	URL                 string                  `json:"url,omitempty"`
+	ThumbnailURL        string                  `json:"thumbnail_url,omitempty"`
+	InputMessageContent InputTextMessageContent `json:"input_message_content"`
+	ReplyMarkup         *InlineKeyboardMarkup   `json:"reply_markup,omitempty"`
+}
+
+type InputTextMessageContent struct {
+	MessageText string `json:"message_text"`
+	ParseMode   string `json:"parse_mode,omitempty"`
+}
+
+// NewInlineQueryResultArticle returns an inline result that sends text when
+// chosen.
+func NewInlineQueryResultArticle(id string, title string, text string, parseMode string) InlineQueryResultArticle {
+	return InlineQueryResultArticle{
+		Type:  "article",
+		ID:    id,
+		Title: title,
+		InputMessageContent: InputTextMessageContent{
+			MessageText: text,
+			ParseMode:   parseMode,
+		},
+	}
+}
+
+// InlineQueryAnswer is an answer to an inline query.
+type InlineQueryAnswer struct {
+	Results []InlineQueryResultArticle
+	// NextOffset is sent back in the next query when the user scrolls to the
+	// end of the results. Empty means there are no more results.
+	NextOffset string
+	// CacheTime is how long, in seconds, Telegram may cache the answer.
+	CacheTime int
+	// IsPersonal restricts the cached answer to the user who sent the query.
+	IsPersonal bool
+}
+
+// AnswerInlineQuery sends the results of an inline query.
+func (c *Client) AnswerInlineQuery(ctx context.Context, queryID string, answer InlineQueryAnswer) error {
+	if len(answer.Results) > maxInlineResults {
+		return fmt.Errorf("can't answer inline query with %d results", len(answer.Results))
+	}
+
+	results := answer.Results
+	if results == nil {
+		results = []InlineQueryResultArticle{}
+	}
+
+	data, err := json.Marshal(results)
+	if err != nil {
+		return fmt.Errorf("can't marshal results: %w", err)
+	}
+
+	q := url.Values{}
+	q.Add("inline_query_id", queryID)
+	q.Add("results", string(data))
+	q.Add("cache_time", strconv.Itoa(answer.CacheTime))
+	if answer.NextOffset != "" {
+		q.Add("next_offset", answer.NextOffset)
+	}
+	if answer.IsPersonal {
+		q.Add("is_personal", "true")
+	}
+
+	data, err = c.doRequest(ctx, answerInlineQueryMethod, q)
+	if err != nil {
+		return fmt.Errorf("can't answer inline query: %w", err)
+	}
+
+	if _, err := parseResponse(data); err != nil {
+		return fmt.Errorf("can't answer inline query: %w", err)
+	}
+
+	return nil
+}
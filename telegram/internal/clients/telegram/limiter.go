@@ -0,0 +1,145 @@
+package telegram
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// Telegram limits for bots: about 30 messages per second overall, one message
+// per second to a private chat and 20 messages per minute to a group.
+const (
+	globalRate = 30
+	chatRate   = 1
+	groupRate  = 20.0 / 60
+
+	// idleBucketTTL is how long a per-chat bucket is kept after its last send.
+	idleBucketTTL = time.Minute
+)
+
+// bucket is a token bucket. Tokens may go negative: a negative balance is
+// the queue of senders that already reserved a token and are waiting for it.
+type bucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newBucket(rate float64, burst float64, now time.Time) *bucket {
+	return &bucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   now,
+	}
+}
+
+// reserve takes a token and returns how long the caller must wait before
+// using it.
+func (b *bucket) reserve(now time.Time) time.Duration {
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+
+	if b.tokens >= 0 {
+		return 0
+	}
+
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// limiter schedules sends so that the global and per-chat limits are kept.
+type limiter struct {
+	mu          sync.Mutex
+	global      *bucket
+	chats       map[int]*bucket
+	pausedUntil time.Time
+	lastPrune   time.Time
+	// now is the clock of the limiter, replaced in tests.
+	now func() time.Time
+}
+
+func newLimiter() *limiter {
+	return newLimiterAt(time.Now)
+}
+
+func newLimiterAt(now func() time.Time) *limiter {
+	return &limiter{
+		global:    newBucket(globalRate, globalRate, now()),
+		chats:     make(map[int]*bucket),
+		lastPrune: now(),
+		now:       now,
+	}
+}
+
+// wait blocks until a message may be sent to chatID.
+func (l *limiter) wait(ctx context.Context, chatID int) error {
+	delay := l.reserve(chatID)
+	if delay <= 0 {
+		return nil
+	}
+
+	throttledSends.Add(1)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (l *limiter) reserve(chatID int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.prune(now)
+
+	chat, ok := l.chats[chatID]
+	if !ok {
+		// Group chats have negative ids.
+		rate := float64(chatRate)
+		if chatID < 0 {
+			rate = groupRate
+		}
+		chat = newBucket(rate, 1, now)
+		l.chats[chatID] = chat
+	}
+
+	delay := max(chat.reserve(now), l.global.reserve(now))
+
+	if paused := l.pausedUntil.Sub(now); paused > delay {
+		delay = paused
+	}
+
+	return delay
+}
+
+// pause holds back every send for d, used when Telegram answers 429.
+func (l *limiter) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := l.now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+func (l *limiter) prune(now time.Time) {
+	if now.Sub(l.lastPrune) < idleBucketTTL {
+		return
+	}
+	l.lastPrune = now
+
+	for id, b := range l.chats {
+		if now.Sub(b.last) > idleBucketTTL {
+			delete(l.chats, id)
+		}
+	}
+}
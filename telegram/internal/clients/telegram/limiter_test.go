@@ -0,0 +1,177 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a limiter clock that moves only when told to or by tick.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+	// tick is added to the time after every reading.
+	tick time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now
+	c.now = c.now.Add(c.tick)
+
+	return now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+}
+
+func TestLimiterGlobalBucket(t *testing.T) {
+	l := newLimiterAt(newFakeClock().Now)
+
+	// Every chat is new, so only the global bucket holds sends back.
+	for chatID := 1; chatID <= globalRate; chatID++ {
+		if delay := l.reserve(chatID); delay != 0 {
+			t.Fatalf("send %d delayed by %v, want none within the burst", chatID, delay)
+		}
+	}
+
+	want := time.Second / globalRate
+	if delay := l.reserve(globalRate + 1); delay != want {
+		t.Errorf("send over the burst delayed by %v, want %v", delay, want)
+	}
+}
+
+func TestLimiterChatBuckets(t *testing.T) {
+	tests := []struct {
+		name   string
+		chatID int
+		want   time.Duration
+	}{
+		{name: "private chat", chatID: 42, want: time.Second},
+		{name: "group", chatID: -42, want: 3 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newFakeClock()
+			l := newLimiterAt(clock.Now)
+
+			if delay := l.reserve(tt.chatID); delay != 0 {
+				t.Fatalf("first send delayed by %v, want none", delay)
+			}
+			if delay := l.reserve(tt.chatID); delay != tt.want {
+				t.Errorf("second send delayed by %v, want %v", delay, tt.want)
+			}
+			if delay := l.reserve(tt.chatID + 1); delay != 0 {
+				t.Errorf("send to another chat delayed by %v, want none", delay)
+			}
+
+			// The two reserved sends take two intervals to pay back.
+			clock.Advance(2 * tt.want)
+			if delay := l.reserve(tt.chatID); delay != 0 {
+				t.Errorf("send after the interval delayed by %v, want none", delay)
+			}
+		})
+	}
+}
+
+func TestLimiterPause(t *testing.T) {
+	clock := newFakeClock()
+	l := newLimiterAt(clock.Now)
+
+	l.pause(5 * time.Second)
+	// A shorter pause doesn't cut the longer one.
+	l.pause(time.Second)
+
+	if delay := l.reserve(1); delay != 5*time.Second {
+		t.Errorf("send during the pause delayed by %v, want 5s", delay)
+	}
+
+	clock.Advance(5 * time.Second)
+	if delay := l.reserve(2); delay != 0 {
+		t.Errorf("send after the pause delayed by %v, want none", delay)
+	}
+}
+
+func TestLimiterPrunesIdleBuckets(t *testing.T) {
+	clock := newFakeClock()
+	l := newLimiterAt(clock.Now)
+
+	l.reserve(1)
+	clock.Advance(idleBucketTTL / 2)
+	l.reserve(2)
+	clock.Advance(idleBucketTTL/2 + time.Second)
+	l.reserve(3)
+
+	if _, ok := l.chats[1]; ok {
+		t.Errorf("bucket of chat 1 idle for %v is kept", idleBucketTTL+time.Second)
+	}
+	for _, chatID := range []int{2, 3} {
+		if _, ok := l.chats[chatID]; !ok {
+			t.Errorf("bucket of recently used chat %d is pruned", chatID)
+		}
+	}
+}
+
+func TestSendPausesAfterTooManyRequests(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		first := requests == 1
+		mu.Unlock()
+
+		if first {
+			fmt.Fprint(w, `{"ok":false,"error_code":429,"description":"Too Many Requests: retry after 7","parameters":{"retry_after":7}}`)
+			return
+		}
+
+		fmt.Fprint(w, `{"ok":true,"result":{"message_id":1}}`)
+	}))
+	t.Cleanup(srv.Close)
+
+	base, err := url.Parse(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every reading of the clock moves it 7 seconds on, so the retry finds
+	// the pause over instead of sleeping through it.
+	clock := newFakeClock()
+	clock.tick = 7 * time.Second
+	start := clock.now
+
+	c := New(*base, "token", WithHTTPClient(srv.Client()))
+	c.limiter = newLimiterAt(clock.Now)
+
+	if err := c.SendMessage(context.Background(), 42, "text"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	if requests != 2 {
+		t.Errorf("sendMessage requested %d times, want 2", requests)
+	}
+
+	if paused := c.limiter.pausedUntil.Sub(start); paused < 7*time.Second {
+		t.Errorf("sends paused for %v, want at least the 7s of retry_after", paused)
+	}
+}
@@ -0,0 +1,212 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"unicode/utf16"
+)
+
+const (
+	sendPhotoMethod      = "sendPhoto"
+	sendMediaGroupMethod = "sendMediaGroup"
+)
+
+const (
+	// maxCaptionLength is the Telegram limit for media captions in UTF-16
+	// code units.
+	maxCaptionLength = 1024
+	// maxMediaGroupSize is the number of items a media group may contain.
+	maxMediaGroupSize = 10
+)
+
+type InputMediaPhoto struct {
+	Type      string `json:"type"`
+	Media     string `json:"media"`
+	Caption   string `json:"caption,omitempty"`
+	ParseMode string `json:"parse_mode,omitempty"`
+}
+
+// NewInputMediaPhoto returns a media group item for the photo at photoURL.
+func NewInputMediaPhoto(photoURL string, caption string, parseMode string) InputMediaPhoto {
+	return InputMediaPhoto{
+		Type:      "photo",
+		Media:     photoURL,
+		Caption:   caption,
+		ParseMode: parseMode,
+	}
+}
+
+// SendPhoto sends the photo at photoURL with caption. A caption longer than
+// Telegram allows is split: the photo gets the first part and the rest
+// follows as text messages, the last of which carries the reply markup.
+func (c *Client) SendPhoto(ctx context.Context, chatID int, photoURL string, caption string, opts ...SendOption) error {
+	_, err := c.SendPhotoWithIDs(ctx, chatID, photoURL, caption, opts...)
+	return err
+}
+
+// SendPhotoWithIDs is SendPhoto that returns the ids of the photo message and
+// of the messages the rest of the caption is sent as. If the photo is sent
+// but the rest of the caption isn't, the ids sent come with the error.
+func (c *Client) SendPhotoWithIDs(ctx context.Context, chatID int, photoURL string, caption string, opts ...SendOption) ([]int, error) {
+	caption, rest := cutText(caption, maxCaptionLength, parseMode(opts))
+
+	q := url.Values{}
+	q.Add("chat_id", strconv.Itoa(chatID))
+	q.Add("photo", photoURL)
+	q.Add("caption", caption)
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if rest != "" {
+		q.Del("reply_markup")
+	}
+
+	result, err := c.send(ctx, chatID, sendPhotoMethod, q)
+	if errors.Is(err, ErrRemoteFile) {
+		result, err = c.uploadPhoto(ctx, chatID, photoURL, q)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't send photo: %w", err)
+	}
+
+	messageIDs := []int{parseMessageID(result)}
+
+	if rest != "" {
+		restIDs, err := c.SendMessageWithIDs(ctx, chatID, rest, opts...)
+		messageIDs = append(messageIDs, restIDs...)
+		if err != nil {
+			return messageIDs, fmt.Errorf("can't send the rest of the caption: %w", err)
+		}
+	}
+
+	return messageIDs, nil
+}
+
+// uploadPhoto sends the photo at photoURL as a file upload, for photos that
+// Telegram can't fetch by URL itself. query holds the other parameters of
+// the sendPhoto request.
+func (c *Client) uploadPhoto(ctx context.Context, chatID int, photoURL string, query url.Values) (json.RawMessage, error) {
+	photo, err := c.download(ctx, photoURL)
+	if err != nil {
+		return nil, fmt.Errorf("can't download photo: %w", err)
+	}
+
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	for key := range query {
+		if key == "photo" {
+			continue
+		}
+		if err := w.WriteField(key, query.Get(key)); err != nil {
+			return nil, err
+		}
+	}
+
+	name := "photo"
+	if u, err := url.Parse(photoURL); err == nil {
+		if base := path.Base(u.Path); base != "/" && base != "." {
+			name = base
+		}
+	}
+
+	part, err := w.CreateFormFile("photo", name)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := part.Write(photo); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+
+	if err := c.limiter.wait(ctx, chatID); err != nil {
+		return nil, err
+	}
+
+	data, err := c.post(ctx, sendPhotoMethod, w.FormDataContentType(), &body)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseResponse(data)
+}
+
+// download returns the file at fileURL if the Bot API server accepts files
+// of its size.
+func (c *Client) download(ctx context.Context, fileURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fileURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.downloads.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, c.maxUpload+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > c.maxUpload {
+		return nil, fmt.Errorf("file is larger than %d bytes", c.maxUpload)
+	}
+
+	return data, nil
+}
+
+// SendMediaGroup sends 2-10 photos as an album.
+func (c *Client) SendMediaGroup(ctx context.Context, chatID int, media []InputMediaPhoto, opts ...SendOption) error {
+	if len(media) < 2 || len(media) > maxMediaGroupSize {
+		return fmt.Errorf("can't send media group of %d items", len(media))
+	}
+
+	for _, m := range media {
+		if captionLength(m.Caption) > maxCaptionLength {
+			return fmt.Errorf("can't send media group: caption is too long")
+		}
+	}
+
+	data, err := json.Marshal(media)
+	if err != nil {
+		return fmt.Errorf("can't marshal media: %w", err)
+	}
+
+	q := url.Values{}
+	q.Add("chat_id", strconv.Itoa(chatID))
+	q.Add("media", string(data))
+
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if _, err := c.send(ctx, chatID, sendMediaGroupMethod, q); err != nil {
+		return fmt.Errorf("can't send media group: %w", err)
+	}
+
+	return nil
+}
+
+// captionLength returns the length of text as Telegram counts it. Markup is
+// counted too, so the result is an upper bound for formatted captions.
+func captionLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
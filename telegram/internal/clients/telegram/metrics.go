@@ -0,0 +1,11 @@
+package telegram
+
+import "expvar"
+
+// Send metrics, published by expvar under /debug/vars.
+var (
+	// throttledSends counts sends delayed by the local rate limiter.
+	throttledSends = expvar.NewInt("telegram_throttled_sends")
+	// rateLimitedSends counts sends rejected by Telegram with 429.
+	rateLimitedSends = expvar.NewInt("telegram_rate_limited_sends")
+)
@@ -0,0 +1,67 @@
+package telegram
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+)
+
+// Parse modes supported by the Bot API.
+const (
+	ParseModeHTML       = "HTML"
+	ParseModeMarkdownV2 = "MarkdownV2"
+)
+
+// SendOption sets an optional parameter of a sent message.
+type SendOption func(q url.Values)
+
+// WithParseMode makes Telegram parse entities in the text with mode.
+func WithParseMode(mode string) SendOption {
+	return func(q url.Values) {
+		q.Set("parse_mode", mode)
+	}
+}
+
+// WithoutLinkPreview disables the preview of the first link in the text.
+func WithoutLinkPreview() SendOption {
+	return func(q url.Values) {
+		q.Set("link_preview_options", `{"is_disabled":true}`)
+	}
+}
+
+// Silent delivers the message without a notification sound.
+func Silent() SendOption {
+	return func(q url.Values) {
+		q.Set("disable_notification", "true")
+	}
+}
+
+// InThread sends the message to a forum topic. Zero means the chat itself.
+func InThread(threadID int) SendOption {
+	return func(q url.Values) {
+		if threadID != 0 {
+			q.Set("message_thread_id", strconv.Itoa(threadID))
+		}
+	}
+}
+
+// WithReplyMarkup attaches an inline keyboard to the message.
+func WithReplyMarkup(markup InlineKeyboardMarkup) SendOption {
+	return func(q url.Values) {
+		data, err := json.Marshal(markup)
+		if err != nil {
+			return
+		}
+		q.Set("reply_markup", string(data))
+	}
+}
+
+// parseMode returns the parse mode set by opts.
+func parseMode(opts []SendOption) string {
+	q := url.Values{}
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	return q.Get("parse_mode")
+}
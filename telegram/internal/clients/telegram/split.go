@@ -0,0 +1,400 @@
+package telegram
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxMessageLength is the Telegram limit for message text in UTF-16 code
+// units after entities parsing.
+const maxMessageLength = 4096
+
+// Kinds of places text may be split at, from the weakest to the strongest.
+const (
+	breakWord = iota
+	breakSentence
+	breakLine
+	breakParagraph
+)
+
+type breakPoint struct {
+	pos   int
+	width int
+}
+
+// splitText splits text into parts of at most limit UTF-16 code units. Parts
+// end at paragraph, line, sentence or word boundaries when possible. Markup
+// of the parse mode is never cut and doesn't count towards the limit:
+// entities left open at the end of a part are closed and reopened in the
+// next, parts with no text outside the markup are dropped.
+func splitText(text string, limit int, mode string) []string {
+	var parts []string
+
+	for text != "" {
+		head, tail := cutText(text, limit, mode)
+		if !isBlank(head, mode) {
+			parts = append(parts, head)
+		}
+		text = tail
+	}
+
+	return parts
+}
+
+// cutText returns the first part of text that fits into limit and the rest.
+func cutText(text string, limit int, mode string) (head string, tail string) {
+	if mode == ParseModeMarkdownV2 {
+		text = splitLinks(text, limit)
+	}
+
+	var (
+		breaks [breakParagraph + 1]breakPoint
+		width  int
+		cut    = -1
+		s      = scanner{mode: mode}
+	)
+
+	for i := 0; i < len(text); {
+		n, w := s.next(text, i)
+		if width+w > limit && width > 0 {
+			cut = i
+			break
+		}
+
+		width += w
+		i += n
+
+		if kind, ok := breakAt(text, i); ok && width > 0 {
+			breaks[kind] = breakPoint{pos: i, width: width}
+		}
+	}
+
+	if cut < 0 {
+		return text, ""
+	}
+
+	// A space that doesn't fit still ends the part before it.
+	if kind, ok := breakAt(text, cut+1); ok {
+		breaks[kind] = breakPoint{pos: cut, width: width}
+	}
+
+	// Prefer the strongest boundary that still leaves the part half full,
+	// then the last boundary of any kind, and cut mid-word as a last resort.
+	pos := 0
+	for kind := breakParagraph; kind >= breakWord; kind-- {
+		if b := breaks[kind]; b.pos > 0 && b.width >= limit/2 {
+			pos = b.pos
+			break
+		}
+	}
+	if pos == 0 {
+		for _, b := range breaks {
+			pos = max(pos, b.pos)
+		}
+	}
+	if pos == 0 {
+		pos = cut
+	}
+
+	head = strings.TrimRightFunc(text[:pos], isSpace)
+	tail = strings.TrimLeftFunc(text[pos:], isSpace)
+
+	open := openEntities(head, mode)
+	if len(open) == 0 {
+		return head, tail
+	}
+
+	// Entities opened at the very end of the part start in the next one
+	// instead of being closed empty.
+	closing := open
+	for len(closing) > 0 && strings.HasSuffix(head, closing[len(closing)-1]) {
+		head = strings.TrimRightFunc(strings.TrimSuffix(head, closing[len(closing)-1]), isSpace)
+		closing = closing[:len(closing)-1]
+	}
+	for i := len(closing) - 1; i >= 0; i-- {
+		head += closingMarkup(closing[i], mode)
+	}
+	if tail != "" {
+		tail = strings.Join(open, "") + tail
+	}
+
+	return head, tail
+}
+
+// scanner reads text token by token and keeps track of the entities opened
+// by the markup of its parse mode.
+type scanner struct {
+	mode string
+	// open are the opening tags or markers of the entities not closed yet.
+	open []string
+	// code is the marker of the MarkdownV2 code entity the scanner is in,
+	// where the other markers are plain text.
+	code string
+}
+
+// next returns the length in bytes of the token at i and its length in
+// UTF-16 code units. In HTML a tag is a token of no width and an entity such
+// as &amp; is one character. In MarkdownV2 a marker is a token of no width,
+// an escaped character is one character and an inline link is a single token
+// as wide as its text.
+func (s *scanner) next(text string, i int) (n int, width int) {
+	switch s.mode {
+	case ParseModeHTML:
+		switch text[i] {
+		case '<':
+			if end := strings.IndexByte(text[i:], '>'); end >= 0 {
+				s.tag(text[i : i+end+1])
+				return end + 1, 0
+			}
+		case '&':
+			if end := strings.IndexByte(text[i:], ';'); end > 1 && end <= 10 {
+				return end + 1, 1
+			}
+		}
+	case ParseModeMarkdownV2:
+		if n, width, ok := s.markdown(text, i); ok {
+			return n, width
+		}
+	}
+
+	return runeAt(text, i)
+}
+
+// tag updates the open entities with an HTML tag.
+func (s *scanner) tag(tag string) {
+	if !strings.HasPrefix(tag, "</") {
+		s.open = append(s.open, tag)
+		return
+	}
+
+	name := tagName(tag)
+	for i := len(s.open) - 1; i >= 0; i-- {
+		if tagName(s.open[i]) == name {
+			s.open = append(s.open[:i], s.open[i+1:]...)
+			return
+		}
+	}
+}
+
+// markdown reads the MarkdownV2 markup at i. It reports false for plain
+// text.
+func (s *scanner) markdown(text string, i int) (n int, width int, ok bool) {
+	if text[i] == '\\' && i+1 < len(text) {
+		n, width := runeAt(text, i+1)
+		return n + 1, width, true
+	}
+
+	rest := text[i:]
+
+	if s.code != "" {
+		if !strings.HasPrefix(rest, s.code) {
+			return 0, 0, false
+		}
+		n := len(s.code)
+		s.code = ""
+		s.open = s.open[:len(s.open)-1]
+		return n, 0, true
+	}
+
+	switch {
+	case strings.HasPrefix(rest, "```"):
+		// The language of a pre block is part of its opening marker.
+		marker := "```"
+		if end := strings.IndexByte(rest, '\n'); end > 3 && !strings.ContainsAny(rest[3:end], " `") {
+			marker = rest[:end+1]
+		}
+		s.code = "```"
+		s.open = append(s.open, marker)
+		return len(marker), 0, true
+	case rest[0] == '`':
+		s.code = "`"
+		s.open = append(s.open, "`")
+		return 1, 0, true
+	case strings.HasPrefix(rest, "__"), strings.HasPrefix(rest, "||"):
+		s.toggle(rest[:2])
+		return 2, 0, true
+	case rest[0] == '*', rest[0] == '_', rest[0] == '~':
+		s.toggle(rest[:1])
+		return 1, 0, true
+	case rest[0] == '[', strings.HasPrefix(rest, "!["):
+		if n, width := linkAt(rest); n > 0 {
+			return n, width, true
+		}
+	}
+
+	return 0, 0, false
+}
+
+// toggle opens the entity of a MarkdownV2 marker or closes the innermost one
+// opened with it.
+func (s *scanner) toggle(marker string) {
+	for i := len(s.open) - 1; i >= 0; i-- {
+		if s.open[i] == marker {
+			s.open = append(s.open[:i], s.open[i+1:]...)
+			return
+		}
+	}
+
+	s.open = append(s.open, marker)
+}
+
+// linkAt returns the length in bytes of the MarkdownV2 inline link or custom
+// emoji at the start of text and the width of its text, or zero if there is
+// none.
+func linkAt(text string) (n int, width int) {
+	start := strings.IndexByte(text, '[') + 1
+
+	end := indexUnescaped(text, start, ']')
+	if end < 0 || end+1 >= len(text) || text[end+1] != '(' {
+		return 0, 0
+	}
+
+	urlEnd := indexUnescaped(text, end+2, ')')
+	if urlEnd < 0 {
+		return 0, 0
+	}
+
+	s := scanner{mode: ParseModeMarkdownV2}
+	for i := start; i < end; {
+		n, w := s.next(text[:end], i)
+		width += w
+		i += n
+	}
+
+	return urlEnd + 1, width
+}
+
+// splitLinks rewrites the MarkdownV2 inline links of text wider than limit
+// as several links to the same URL, separated by spaces, that fit into a
+// part each, as the text of a link is never cut.
+func splitLinks(text string, limit int) string {
+	var (
+		b    strings.Builder
+		last int
+		s    = scanner{mode: ParseModeMarkdownV2}
+	)
+
+	for i := 0; i < len(text); {
+		n, w := s.next(text, i)
+		if w > limit && text[i] == '[' {
+			end := indexUnescaped(text, i+1, ']')
+			label, target := text[i+1:end], text[end+1:i+n]
+
+			b.WriteString(text[last:i])
+			for j, part := range splitText(label, limit, ParseModeMarkdownV2) {
+				if j > 0 {
+					b.WriteByte(' ')
+				}
+				b.WriteString("[" + part + "]" + target)
+			}
+			last = i + n
+		}
+		i += n
+	}
+
+	if last == 0 {
+		return text
+	}
+	b.WriteString(text[last:])
+
+	return b.String()
+}
+
+// indexUnescaped returns the index of the first c in text from i on that
+// isn't escaped with a backslash, or -1.
+func indexUnescaped(text string, i int, c byte) int {
+	for ; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case c:
+			return i
+		}
+	}
+
+	return -1
+}
+
+// runeAt returns the length in bytes of the character at i and its length in
+// UTF-16 code units.
+func runeAt(text string, i int) (n int, width int) {
+	r, n := utf8.DecodeRuneInString(text[i:])
+	if r >= 0x10000 {
+		return n, 2
+	}
+
+	return n, 1
+}
+
+// breakAt reports whether text may be split at pos and the kind of the break.
+func breakAt(text string, pos int) (int, bool) {
+	prefix := text[:pos]
+
+	switch {
+	case strings.HasSuffix(prefix, "\n\n"):
+		return breakParagraph, true
+	case strings.HasSuffix(prefix, "\n"):
+		return breakLine, true
+	case strings.HasSuffix(prefix, ". "), strings.HasSuffix(prefix, "! "),
+		strings.HasSuffix(prefix, "? "), strings.HasSuffix(prefix, "… "):
+		return breakSentence, true
+	case strings.HasSuffix(prefix, " "):
+		return breakWord, true
+	default:
+		return 0, false
+	}
+}
+
+// openEntities returns the opening tags or markers in s whose entities are
+// not closed.
+func openEntities(s string, mode string) []string {
+	sc := scanner{mode: mode}
+	for i := 0; i < len(s); {
+		n, _ := sc.next(s, i)
+		i += n
+	}
+
+	return sc.open
+}
+
+// closingMarkup returns the markup closing the entity opened with open.
+func closingMarkup(open string, mode string) string {
+	switch {
+	case mode == ParseModeHTML:
+		return "</" + tagName(open) + ">"
+	case strings.HasPrefix(open, "```"):
+		return "```"
+	default:
+		return open
+	}
+}
+
+// isBlank reports whether s has no text outside the markup.
+func isBlank(s string, mode string) bool {
+	sc := scanner{mode: mode}
+	for i := 0; i < len(s); {
+		n, w := sc.next(s, i)
+		if w > 0 && strings.TrimFunc(s[i:i+n], isSpace) != "" {
+			return false
+		}
+		i += n
+	}
+
+	return true
+}
+
+// tagName returns the name of an opening or closing tag: "a" for
+// `<a href="...">`.
+func tagName(tag string) string {
+	name := strings.TrimPrefix(strings.TrimSuffix(tag, ">"), "<")
+	name = strings.TrimPrefix(name, "/")
+	if i := strings.IndexAny(name, " \t\n"); i >= 0 {
+		name = name[:i]
+	}
+
+	return name
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\n' || r == '\t' || r == '\r'
+}
@@ -0,0 +1,179 @@
+package telegram
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		mode  string
+		want  []string
+	}{
+		{
+			name:  "fits",
+			text:  "aaaa bbbb",
+			limit: 9,
+			want:  []string{"aaaa bbbb"},
+		},
+		{
+			name:  "words",
+			text:  "aaaa bbbb cccc",
+			limit: 9,
+			want:  []string{"aaaa bbbb", "cccc"},
+		},
+		{
+			name:  "paragraph before a later word",
+			text:  "aaaa\n\nbb cc dd",
+			limit: 10,
+			want:  []string{"aaaa", "bb cc dd"},
+		},
+		{
+			name:  "surrogate pairs",
+			text:  "😀😀😀",
+			limit: 3,
+			want:  []string{"😀", "😀", "😀"},
+		},
+		{
+			name:  "token wider than the limit",
+			text:  "😀😀",
+			limit: 1,
+			want:  []string{"😀", "😀"},
+		},
+		{
+			name:  "HTML entity references",
+			text:  "&amp;&amp;&amp;",
+			limit: 2,
+			mode:  ParseModeHTML,
+			want:  []string{"&amp;&amp;", "&amp;"},
+		},
+		{
+			name:  "HTML reopened entities",
+			text:  `<b><a href="u">aaaa bbbb</a></b>`,
+			limit: 5,
+			mode:  ParseModeHTML,
+			want:  []string{`<b><a href="u">aaaa</a></b>`, `<b><a href="u">bbbb</a></b>`},
+		},
+		{
+			name:  "HTML entity opened at the cut",
+			text:  "aaaa <i>bbbb</i>",
+			limit: 5,
+			mode:  ParseModeHTML,
+			want:  []string{"aaaa", "<i>bbbb</i>"},
+		},
+		{
+			name:  "HTML blank part",
+			text:  "aaaa <b> </b>",
+			limit: 4,
+			mode:  ParseModeHTML,
+			want:  []string{"aaaa"},
+		},
+		{
+			name:  "MarkdownV2 reopened entities",
+			text:  "*_aaaa bbbb_*",
+			limit: 5,
+			mode:  ParseModeMarkdownV2,
+			want:  []string{"*_aaaa_*", "*_bbbb_*"},
+		},
+		{
+			name:  "MarkdownV2 escapes",
+			text:  `aa\.\.bb`,
+			limit: 3,
+			mode:  ParseModeMarkdownV2,
+			want:  []string{`aa\.`, `\.bb`},
+		},
+		{
+			name:  "MarkdownV2 code",
+			text:  "`a*b c`",
+			limit: 3,
+			mode:  ParseModeMarkdownV2,
+			want:  []string{"`a*b`", "`c`"},
+		},
+		{
+			name:  "MarkdownV2 link is not cut",
+			text:  "aa [bb cc](http://x)",
+			limit: 6,
+			mode:  ParseModeMarkdownV2,
+			want:  []string{"aa", "[bb cc](http://x)"},
+		},
+		{
+			name:  "MarkdownV2 link wider than the limit",
+			text:  "[aaaa bbbb](http://x)",
+			limit: 5,
+			mode:  ParseModeMarkdownV2,
+			want:  []string{"[aaaa](http://x)", "[bbbb](http://x)"},
+		},
+		{
+			name:  "MarkdownV2 link wider than the limit with entities",
+			text:  "[*aaaa bbbb*](http://x)",
+			limit: 5,
+			mode:  ParseModeMarkdownV2,
+			want:  []string{"[*aaaa*](http://x)", "[*bbbb*](http://x)"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitText(tt.text, tt.limit, tt.mode)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("splitText(%q, %d) = %q, want %q", tt.text, tt.limit, got, tt.want)
+			}
+
+			for _, part := range got {
+				if w := textWidth(part, tt.mode); w > tt.limit && !singleToken(part, tt.mode) {
+					t.Errorf("part %q is %d wide, want at most %d", part, w, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestCutTextOversizeLink(t *testing.T) {
+	link := "[" + strings.Repeat("word ", 2000) + "](http://x)"
+
+	for text := link; text != ""; {
+		head, tail := cutText(text, maxMessageLength, ParseModeMarkdownV2)
+		if w := textWidth(head, ParseModeMarkdownV2); w > maxMessageLength {
+			t.Fatalf("cutText() part is %d wide, want at most %d", w, maxMessageLength)
+		}
+		if tail == text {
+			t.Fatalf("cutText() made no progress")
+		}
+		text = tail
+	}
+}
+
+// textWidth returns the width of text outside the markup.
+func textWidth(text string, mode string) int {
+	var width int
+
+	s := scanner{mode: mode}
+	for i := 0; i < len(text); {
+		n, w := s.next(text, i)
+		width += w
+		i += n
+	}
+
+	return width
+}
+
+// singleToken reports whether text has one token with width, which a part
+// holds even if it is wider than the limit.
+func singleToken(text string, mode string) bool {
+	var tokens int
+
+	s := scanner{mode: mode}
+	for i := 0; i < len(text); {
+		n, w := s.next(text, i)
+		if w > 0 {
+			tokens++
+		}
+		i += n
+	}
+
+	return tokens == 1
+}
@@ -1,33 +1,127 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
 	"strconv"
+	"time"
 )
 
 type Client struct {
-	host     string
+	// baseURL is the Bot API server, e.g. https://api.telegram.org.
+	baseURL  url.URL
 	basePath string
-	client   http.Client
+	client   *http.Client
+	// downloads fetches the images to upload. It doesn't go through the Bot
+	// API client's proxy and timeout.
+	downloads *http.Client
+	limiter   *limiter
+	// maxUpload is the size of the largest file the server accepts.
+	maxUpload int64
 }
 
 const (
-	getUpdatesMethod  = "getUpdates"
-	sendMessageMethod = "sendMessage"
+	getUpdatesMethod          = "getUpdates"
+	sendMessageMethod         = "sendMessage"
+	answerCallbackQueryMethod = "answerCallbackQuery"
+	setMyCommandsMethod       = "setMyCommands"
+	getMeMethod               = "getMe"
+	getChatMemberMethod       = "getChatMember"
+	getChatMethod             = "getChat"
 )
 
-func New(host string, token string) *Client {
-	return &Client{
-		host:     host,
-		basePath: newBasePath(token),
-		client:   http.Client{},
+// Scopes of the command menu.
+const (
+	ScopeDefault         = "default"
+	ScopeAllPrivateChats = "all_private_chats"
+	ScopeAllGroupChats   = "all_group_chats"
+	ScopeChat            = "chat"
+)
+
+// maxRetries is how many times a send rejected with 429 is repeated.
+const maxRetries = 3
+
+// DefaultBaseURL is the address of the Telegram cloud Bot API server.
+const DefaultBaseURL = "https://api.telegram.org"
+
+// Upload limits of the cloud Bot API and of a self-hosted telegram-bot-api
+// server started with --local.
+const (
+	maxCloudUpload = 50 << 20
+	maxLocalUpload = 2000 << 20
+)
+
+// downloadTimeout bounds the download of an image to upload.
+const downloadTimeout = 2 * time.Minute
+
+// jsonFields are the parameters that are JSON-serialized objects or arrays.
+// Every other parameter is sent as a string, even user text that looks like
+// JSON.
+var jsonFields = map[string]bool{
+	"reply_markup":         true,
+	"media":                true,
+	"results":              true,
+	"commands":             true,
+	"scope":                true,
+	"link_preview_options": true,
+}
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithHTTPClient makes the client send requests with hc, e.g. one with
+// timeouts or a proxy.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.client = hc
+	}
+}
+
+// WithLocalServer tells the client that baseURL is a self-hosted Bot API
+// server in local mode, which accepts much larger files.
+func WithLocalServer() Option {
+	return func(c *Client) {
+		c.maxUpload = maxLocalUpload
+	}
+}
+
+// New returns a client of the Bot API server at baseURL.
+func New(baseURL url.URL, token string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:   baseURL,
+		basePath:  newBasePath(token),
+		client:    &http.Client{},
+		downloads: &http.Client{Timeout: downloadTimeout},
+		limiter:   newLimiter(),
+		maxUpload: maxCloudUpload,
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// NewHTTPClient returns an HTTP client for the Bot API with a timeout per
+// request and, if proxy is not nil, a proxy for every request.
+func NewHTTPClient(timeout time.Duration, proxy *url.URL) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != nil {
+		transport.Proxy = http.ProxyURL(proxy)
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
 	}
 }
 
@@ -54,71 +148,319 @@ func (c *Client) Updates(ctx context.Context, offset int, limit int) (updates []
 	return res.Result, nil
 }
 
-func (c *Client) SendMessage(ctx context.Context, chatID int, text string) error {
+// SendMessage sends text to the chat. Text longer than Telegram allows is
+// sent as several messages in order, the reply markup is attached to the last
+// one.
+func (c *Client) SendMessage(ctx context.Context, chatID int, text string, opts ...SendOption) error {
+	_, err := c.SendMessageWithIDs(ctx, chatID, text, opts...)
+	return err
+}
+
+// SendMessageWithID is SendMessage that returns the id of the first message
+// sent.
+func (c *Client) SendMessageWithID(ctx context.Context, chatID int, text string, opts ...SendOption) (int, error) {
+	messageIDs, err := c.SendMessageWithIDs(ctx, chatID, text, opts...)
+	if err != nil {
+		return 0, err
+	}
+
+	return messageIDs[0], nil
+}
+
+// SendMessageWithIDs is SendMessage that returns the ids of every message
+// sent. If a part of the text isn't sent, the ids of the parts before it come
+// with the error.
+func (c *Client) SendMessageWithIDs(ctx context.Context, chatID int, text string, opts ...SendOption) ([]int, error) {
+	parts := splitText(text, maxMessageLength, parseMode(opts))
+	if len(parts) == 0 {
+		parts = []string{text}
+	}
+
+	messageIDs := make([]int, 0, len(parts))
+	for i, part := range parts {
+		messageID, err := c.sendPart(ctx, chatID, part, i == len(parts)-1, opts)
+		if err != nil {
+			return messageIDs, fmt.Errorf("can't send message: %w", err)
+		}
+
+		messageIDs = append(messageIDs, messageID)
+	}
+
+	return messageIDs, nil
+}
+
+// sendPart sends a part of a split text. Only the last part gets the reply
+// markup.
+func (c *Client) sendPart(ctx context.Context, chatID int, part string, last bool, opts []SendOption) (int, error) {
 	q := url.Values{}
 	q.Add("chat_id", strconv.Itoa(chatID))
-	q.Add("text", text)
+	q.Add("text", part)
 
-	data, err := c.doRequest(ctx, sendMessageMethod, q)
+	for _, opt := range opts {
+		opt(q)
+	}
+
+	if !last {
+		q.Del("reply_markup")
+	}
+
+	result, err := c.send(ctx, chatID, sendMessageMethod, q)
 	if err != nil {
-		return fmt.Errorf("can't send message: %w", err)
+		return 0, err
 	}
 
-	var response struct {
-		Ok    bool   `json:"ok"`
-		Error string `json:"description"`
+	return parseMessageID(result), nil
+}
+
+// AnswerCallbackQuery acknowledges a callback query. A non-empty text is
+// shown to the user as a notification.
+func (c *Client) AnswerCallbackQuery(ctx context.Context, queryID string, text string) error {
+	q := url.Values{}
+	q.Add("callback_query_id", queryID)
+	if text != "" {
+		q.Add("text", text)
+	}
+
+	data, err := c.doRequest(ctx, answerCallbackQueryMethod, q)
+	if err != nil {
+		return fmt.Errorf("can't answer callback query: %w", err)
 	}
 
+	if _, err := parseResponse(data); err != nil {
+		return fmt.Errorf("can't answer callback query: %w", err)
+	}
+
+	return nil
+}
+
+// GetMe returns the bot's own user.
+func (c *Client) GetMe(ctx context.Context) (From, error) {
+	data, err := c.doRequest(ctx, getMeMethod, url.Values{})
+	if err != nil {
+		return From{}, fmt.Errorf("can't get me: %w", err)
+	}
+
+	result, err := parseResponse(data)
+	if err != nil {
+		return From{}, fmt.Errorf("can't get me: %w", err)
+	}
+
+	var me From
+	if err := json.Unmarshal(result, &me); err != nil {
+		return From{}, fmt.Errorf("can`t Unmarshall: %w", err)
+	}
+
+	return me, nil
+}
+
+// GetChat returns the chat with the given id or @username.
+func (c *Client) GetChat(ctx context.Context, chatID string) (Chat, error) {
+	q := url.Values{}
+	q.Add("chat_id", chatID)
+
+	data, err := c.doRequest(ctx, getChatMethod, q)
+	if err != nil {
+		return Chat{}, fmt.Errorf("can't get chat: %w", err)
+	}
+
+	result, err := parseResponse(data)
+	if err != nil {
+		return Chat{}, fmt.Errorf("can't get chat: %w", err)
+	}
+
+	var chat Chat
+	if err := json.Unmarshal(result, &chat); err != nil {
+		return Chat{}, fmt.Errorf("can`t Unmarshall: %w", err)
+	}
+
+	return chat, nil
+}
+
+// GetChatMember returns the membership of userID in chatID.
+func (c *Client) GetChatMember(ctx context.Context, chatID int, userID int) (ChatMember, error) {
+	q := url.Values{}
+	q.Add("chat_id", strconv.Itoa(chatID))
+	q.Add("user_id", strconv.Itoa(userID))
+
+	data, err := c.doRequest(ctx, getChatMemberMethod, q)
+	if err != nil {
+		return ChatMember{}, fmt.Errorf("can't get chat member: %w", err)
+	}
+
+	result, err := parseResponse(data)
+	if err != nil {
+		return ChatMember{}, fmt.Errorf("can't get chat member: %w", err)
+	}
+
+	var member ChatMember
+	if err := json.Unmarshal(result, &member); err != nil {
+		return ChatMember{}, fmt.Errorf("can`t Unmarshall: %w", err)
+	}
+
+	return member, nil
+}
+
+// SetMyCommands sets the command menu shown to users in scope. An empty
+// languageCode sets the commands for users with no dedicated list.
+func (c *Client) SetMyCommands(ctx context.Context, commands []BotCommand, scope BotCommandScope, languageCode string) error {
+	cmds, err := json.Marshal(commands)
+	if err != nil {
+		return fmt.Errorf("can't marshal commands: %w", err)
+	}
+
+	sc, err := json.Marshal(scope)
+	if err != nil {
+		return fmt.Errorf("can't marshal scope: %w", err)
+	}
+
+	q := url.Values{}
+	q.Add("commands", string(cmds))
+	q.Add("scope", string(sc))
+	if languageCode != "" {
+		q.Add("language_code", languageCode)
+	}
+
+	data, err := c.doRequest(ctx, setMyCommandsMethod, q)
+	if err != nil {
+		return fmt.Errorf("can't set commands: %w", err)
+	}
+
+	if _, err := parseResponse(data); err != nil {
+		return fmt.Errorf("can't set commands: %w", err)
+	}
+
+	return nil
+}
+
+// send performs a rate limited request to chatID and returns its result.
+// Requests rejected with 429 are repeated after the retry_after delay.
+func (c *Client) send(ctx context.Context, chatID int, method string, query url.Values) (json.RawMessage, error) {
+	for attempt := 0; ; attempt++ {
+		if err := c.limiter.wait(ctx, chatID); err != nil {
+			return nil, err
+		}
+
+		data, err := c.doRequest(ctx, method, query)
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := parseResponse(data)
+
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == http.StatusTooManyRequests && attempt < maxRetries {
+			rateLimitedSends.Add(1)
+
+			retryAfter := time.Duration(apiErr.RetryAfter) * time.Second
+			if retryAfter <= 0 {
+				retryAfter = time.Second
+			}
+			slog.Warn("Telegram rate limit hit", "chat_id", chatID, "retry_after", retryAfter)
+
+			c.limiter.pause(retryAfter)
+
+			continue
+		}
+
+		return result, err
+	}
+}
+
+// parseMessageID returns the id of the message in a send result, zero if
+// the result can't be parsed.
+func parseMessageID(result json.RawMessage) int {
+	var msg Message
+	if err := json.Unmarshal(result, &msg); err != nil {
+		return 0
+	}
+
+	return msg.MessageID
+}
+
+// jsonParams converts query parameters to a JSON object. The jsonFields are
+// embedded as they are, the rest are sent as strings, which the Bot API
+// accepts for numbers and booleans too.
+func jsonParams(query url.Values) map[string]any {
+	params := make(map[string]any, len(query))
+	for key := range query {
+		value := query.Get(key)
+
+		if jsonFields[key] && json.Valid([]byte(value)) {
+			params[key] = json.RawMessage(value)
+			continue
+		}
+
+		params[key] = value
+	}
+
+	return params
+}
+
+func parseResponse(data []byte) (json.RawMessage, error) {
+	var response APIResponse
+
 	if err := json.Unmarshal(data, &response); err != nil {
-		return fmt.Errorf("can't parse Telegram response: %w", err)
+		return nil, fmt.Errorf("can't parse Telegram response: %w", err)
 	}
 
 	if !response.Ok {
-		return fmt.Errorf("Telegram API error: %s", response.Error)
+		apiErr := &APIError{
+			Code:        response.ErrorCode,
+			Description: response.Description,
+		}
+		if response.Parameters != nil {
+			apiErr.RetryAfter = response.Parameters.RetryAfter
+		}
+
+		return nil, apiErr
 	}
 
-	return nil
+	return response.Result, nil
 }
 
+// doRequest calls a Bot API method with the query parameters sent as a JSON
+// object.
 func (c *Client) doRequest(ctx context.Context, method string, query url.Values) (data []byte, err error) {
+	body, err := json.Marshal(jsonParams(query))
+	if err != nil {
+		return nil, fmt.Errorf("can't marshal parameters: %w", err)
+	}
+
+	slog.Debug("Telegram request", "method", method, "params", string(body))
+
+	return c.post(ctx, method, "application/json", bytes.NewReader(body))
+}
+
+// post sends a request body to a Bot API method and returns the response.
+func (c *Client) post(ctx context.Context, method string, contentType string, params io.Reader) (data []byte, err error) {
 	defer func() {
 		if err != nil {
-			log.Printf("Telegram request error: %v", err)
+			slog.Error("Telegram request failed", "method", method, "error", err)
 		}
 	}()
 
-	u := url.URL{
-		Scheme: "https",
-		Host:   c.host,
-		Path:   path.Join(c.basePath, method),
-	}
-
-	log.Printf("Making request to URL: %s", u.String())
-	log.Printf("Query parameters: %v", query)
+	u := c.baseURL
+	u.Path = path.Join("/", u.Path, c.basePath, method)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), params)
 	if err != nil {
-		log.Printf("Error creating request: %v", err)
 		return nil, err
 	}
 
-	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := c.client.Do(req)
 	if err != nil {
-		log.Printf("Error executing request: %v", err)
 		return nil, err
 	}
 	defer func() { _ = resp.Body.Close() }()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		log.Printf("Error reading response body: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Response status code: %d", resp.StatusCode)
-	log.Printf("Response body: %s", string(body))
+	slog.Debug("Telegram response", "method", method, "status", resp.StatusCode, "body", string(body))
 
 	return body, nil
 }
@@ -1,25 +1,126 @@
 package telegram
 
+import "encoding/json"
+
+type APIResponse struct {
+	Ok          bool                `json:"ok"`
+	ErrorCode   int                 `json:"error_code"`
+	Description string              `json:"description"`
+	Parameters  *ResponseParameters `json:"parameters"`
+	Result      json.RawMessage     `json:"result"`
+}
+
+type ResponseParameters struct {
+	RetryAfter      int `json:"retry_after"`
+	MigrateToChatID int `json:"migrate_to_chat_id"`
+}
+
 type UpdatesResponse struct {
 	Ok     bool     `json:"ok"`
 	Result []Update `json:"result"`
 }
 
 type Update struct {
-	ID      int              `json:"update_id"`
-	Message *IncomingMessage `json:"message"`
+	ID            int              `json:"update_id"`
+	Message       *IncomingMessage `json:"message"`
+	CallbackQuery *CallbackQuery   `json:"callback_query"`
+	// MyChatMember reports changes of the bot's own membership in a chat.
+	MyChatMember *ChatMemberUpdated `json:"my_chat_member"`
+	InlineQuery  *InlineQuery       `json:"inline_query"`
+}
+
+// Message is a message sent by the bot.
+type Message struct {
+	MessageID int  `json:"message_id"`
+	Chat      Chat `json:"chat"`
 }
 
 type IncomingMessage struct {
-	Text string `json:"text"`
-	From From   `json:"from"`
-	Chat Chat   `json:"chat"`
+	Text            string `json:"text"`
+	From            From   `json:"from"`
+	Chat            Chat   `json:"chat"`
+	SenderChat      *Chat  `json:"sender_chat"`
+	MigrateToChatID int    `json:"migrate_to_chat_id"`
+	// MessageThreadID is the forum topic of the message when IsTopicMessage
+	// is set. Otherwise it may be a thread of replies.
+	MessageThreadID int  `json:"message_thread_id"`
+	IsTopicMessage  bool `json:"is_topic_message"`
 }
 
 type From struct {
-	Username string `json:"username"`
+	ID           int    `json:"id"`
+	Username     string `json:"username"`
+	LanguageCode string `json:"language_code"`
 }
 
 type Chat struct {
-	ID int `json:"id"`
+	ID   int    `json:"id"`
+	Type string `json:"type"`
+	// IsForum reports a supergroup with topics enabled.
+	IsForum bool `json:"is_forum"`
+}
+
+// Chat types.
+const (
+	ChatPrivate    = "private"
+	ChatGroup      = "group"
+	ChatSupergroup = "supergroup"
+	ChatChannel    = "channel"
+)
+
+type ChatMember struct {
+	Status string `json:"status"`
+}
+
+// Chat member statuses.
+const (
+	MemberCreator       = "creator"
+	MemberAdministrator = "administrator"
+	MemberMember        = "member"
+	MemberRestricted    = "restricted"
+	MemberLeft          = "left"
+	MemberKicked        = "kicked"
+)
+
+type ChatMemberUpdated struct {
+	Chat          Chat       `json:"chat"`
+	From          From       `json:"from"`
+	OldChatMember ChatMember `json:"old_chat_member"`
+	NewChatMember ChatMember `json:"new_chat_member"`
+}
+
+type CallbackQuery struct {
+	ID      string           `json:"id"`
+	From    From             `json:"from"`
+	Message *IncomingMessage `json:"message"`
+	Data    string           `json:"data"`
+}
+
+type InlineQuery struct {
+	ID    string `json:"id"`
+	From  From   `json:"from"`
+	Query string `json:"query"`
+	// Offset is the next_offset of the previous answer when the user
+	// scrolls the results.
+	Offset string `json:"offset"`
+}
+
+type BotCommand struct {
+	Command     string `json:"command"`
+	Description string `json:"description"`
+}
+
+type BotCommandScope struct {
+	Type   string `json:"type"`
+	ChatID int    `json:"chat_id,omitempty"`
+}
+
+type InlineKeyboardMarkup struct {
+	InlineKeyboard [][]InlineKeyboardButton `json:"inline_keyboard"`
+}
+
+type InlineKeyboardButton struct {
+	Text         string `json:"text"`
+	URL          string `json:"url,omitempty"`
+	CallbackData string `json:"callback_data,omitempty"`
 }
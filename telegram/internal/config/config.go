@@ -1,22 +1,53 @@
 package config
 
 import (
+	"api/internal/clients/telegram"
 	"log"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
+const (
+	defaultWorkers   = 4
+	defaultTgTimeout = 30 * time.Second
+	// maxBatchSize is the most updates getUpdates returns at once.
+	maxBatchSize = 100
+)
+
 type config struct {
-	TgToken     string
-	TgHost      string
-	AtorToken   string
+	TgToken string
+	// TgAPIURL is the Bot API server, the cloud one or a self-hosted
+	// telegram-bot-api.
+	TgAPIURL url.URL
+	// TgLocalAPI reports a self-hosted server running with --local.
+	TgLocalAPI bool
+	// TgTimeout limits every request to the Bot API.
+	TgTimeout time.Duration
+	// TgProxy is the proxy for requests to the Bot API, nil for none.
+	TgProxy   *url.URL
+	AtorToken string
+	// BatchSize is how many updates may be in work past the oldest one not
+	// handled yet, at most 100. Updates are fetched from the committed
+	// offset so that a crash loses none, and Telegram returns no more than
+	// BatchSize of them: a chat that is slow for longer stalls the others.
 	BatchSize   int
+	Workers     int
 	DbHost      string
 	DbPort      string
 	RabbitUrl   string
 	RabbitQueue string
+	MetricsAddr string
+	// LogLevel is one of debug, info, warn and error.
+	LogLevel string
+	// Channels are the @usernames or ids of channels that get every post.
+	Channels []string
+	// Admins are the ids of the chats allowed to use the admin commands.
+	Admins []int
 }
 
 func MustLoad() *config {
@@ -28,17 +59,112 @@ func MustLoad() *config {
 	if err != nil {
 		log.Fatal("can`t get batch size")
 	}
+	if size < 1 || size > maxBatchSize {
+		log.Fatalf("BATCH_SIZE must be between 1 and %d", maxBatchSize)
+	}
+
+	workers := defaultWorkers
+	if w := os.Getenv("WORKERS"); w != "" {
+		workers, err = strconv.Atoi(w)
+		if err != nil {
+			log.Fatal("can`t get workers count")
+		}
+	}
+
+	timeout := defaultTgTimeout
+	if t := os.Getenv("TELEGRAM_TIMEOUT"); t != "" {
+		timeout, err = time.ParseDuration(t)
+		if err != nil {
+			log.Fatal("can`t get telegram timeout")
+		}
+	}
 
 	cfg := &config{
 		TgToken:     os.Getenv("TELEGRAM_TOKEN"),
-		TgHost:      os.Getenv("TELEGRAM_HOST"),
+		TgAPIURL:    mustParseAPIURL(os.Getenv("TELEGRAM_API_URL"), os.Getenv("TELEGRAM_HOST")),
+		TgLocalAPI:  os.Getenv("TELEGRAM_LOCAL_API") == "true",
+		TgTimeout:   timeout,
+		TgProxy:     mustParseProxy(os.Getenv("TELEGRAM_PROXY")),
 		AtorToken:   os.Getenv("BLOGATOR_TOKEN"),
 		DbHost:      os.Getenv("DB_HOST"),
 		DbPort:      os.Getenv("DB_PORT"),
 		BatchSize:   size,
+		Workers:     workers,
 		RabbitUrl:   os.Getenv("RABBITMQ_URL"),
 		RabbitQueue: os.Getenv("RABBITMQ_QUEUE"),
+		MetricsAddr: os.Getenv("METRICS_ADDR"),
+		LogLevel:    os.Getenv("LOG_LEVEL"),
+		Channels:    splitList(os.Getenv("TELEGRAM_CHANNELS")),
+		Admins:      mustParseIDs(os.Getenv("ADMIN_CHAT_IDS")),
 	}
 	return cfg
 
 }
+
+// Secrets returns the configured values that must never be logged.
+func (c *config) Secrets() []string {
+	secrets := []string{c.TgToken, c.AtorToken, c.RabbitUrl}
+	if c.TgProxy != nil {
+		if password, ok := c.TgProxy.User.Password(); ok {
+			secrets = append(secrets, password)
+		}
+	}
+
+	return secrets
+}
+
+// mustParseAPIURL returns the Bot API server URL. TELEGRAM_HOST is the older
+// setting that names an HTTPS host only.
+func mustParseAPIURL(rawURL string, host string) url.URL {
+	switch {
+	case rawURL != "":
+	case host != "":
+		rawURL = "https://" + host
+	default:
+		rawURL = telegram.DefaultBaseURL
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("invalid telegram API URL %q", rawURL)
+	}
+
+	return *u
+}
+
+func mustParseProxy(rawURL string) *url.URL {
+	if rawURL == "" {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		log.Fatal("invalid telegram proxy URL")
+	}
+
+	return u
+}
+
+func mustParseIDs(s string) []int {
+	var ids []int
+	for _, item := range splitList(s) {
+		id, err := strconv.Atoi(item)
+		if err != nil {
+			log.Fatalf("invalid chat id %q", item)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
+
+func splitList(s string) []string {
+	var res []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			res = append(res, item)
+		}
+	}
+
+	return res
+}
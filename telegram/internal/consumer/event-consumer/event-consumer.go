@@ -4,56 +4,182 @@ import (
 	"api/internal/events"
 	"context"
 	"log"
+	"sync"
 	"time"
 )
 
+const (
+	// commitTimeout bounds the last commit on shutdown.
+	commitTimeout = 5 * time.Second
+	// idleDelay is how long to wait before fetching again when there is
+	// nothing new and no event has been handled.
+	idleDelay = time.Second
+)
+
 type Consumer struct {
 	fetcher   events.Fetcher
 	processor events.Processor
 	batchSize int
+	workers   int
+}
+
+// job is a single event queued to a worker.
+type job struct {
+	event events.Event
+	seq   int
 }
 
-func New(fetcher events.Fetcher, processor events.Processor, batchSize int) Consumer {
+func New(fetcher events.Fetcher, processor events.Processor, batchSize int, workers int) Consumer {
+	if workers < 1 {
+		workers = 1
+	}
+
 	return Consumer{
 		fetcher:   fetcher,
 		processor: processor,
 		batchSize: batchSize,
+		workers:   workers,
 	}
 }
 
-func (c Consumer) Start() error {
+// Start fetches events until ctx is cancelled. Events are spread over
+// long-lived workers by chat id, so chats are handled in parallel while the
+// events of one chat keep their order. Fetching goes on while the workers are
+// busy, as far as the fetcher lets events get ahead of the oldest one in
+// work. Only the events handled without a gap are committed, so after a
+// restart nothing is skipped, but events handled behind an unfinished one
+// are received again. On shutdown the events still queued are left for the
+// next run.
+func (c Consumer) Start(ctx context.Context) error {
+	progress := newProgress()
+
+	queues := make([]chan job, c.workers)
+	var wg sync.WaitGroup
+
+	for i := range queues {
+		queues[i] = make(chan job, c.batchSize)
+
+		wg.Add(1)
+		go func(queue <-chan job) {
+			defer wg.Done()
+
+			for j := range queue {
+				if ctx.Err() != nil {
+					continue
+				}
+
+				if c.handleEvent(ctx, j.event) {
+					progress.finish(j.seq)
+				}
+			}
+		}(queues[i])
+	}
+
+	defer func() {
+		for _, queue := range queues {
+			close(queue)
+		}
+		wg.Wait()
+
+		commitCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), commitTimeout)
+		defer cancel()
+
+		if err := c.commit(commitCtx, progress); err != nil {
+			log.Printf("[ERR] consumer: %s", err.Error())
+		}
+	}()
+
 	for {
-		gotEvents, err := c.fetcher.Fetch(context.Background(), c.batchSize)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		gotEvents, err := c.fetcher.Fetch(ctx, c.batchSize)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
 			log.Printf("[ERR] consumer: %s", err.Error())
 
 			continue
 		}
 
+		if err := c.commit(ctx, progress); err != nil {
+			log.Printf("[ERR] consumer: %s", err.Error())
+		}
+
+		// Nothing new either means no updates or that the fetcher waits
+		// for the events in work, so fetch again once one is handled.
 		if len(gotEvents) == 0 {
-			time.Sleep(1 * time.Second)
+			select {
+			case <-ctx.Done():
+			case <-progress.advanced():
+			case <-time.After(idleDelay):
+			}
 
 			continue
 		}
 
-		if err := c.handleEvents(context.Background(), gotEvents); err != nil {
-			log.Print(err)
-
-			continue
+		if !c.dispatch(ctx, queues, progress, gotEvents) {
+			return nil
 		}
 	}
 }
 
-func (c *Consumer) handleEvents(ctx context.Context, events []events.Event) error {
+// dispatch queues the events to the workers. A queue holds a whole batch,
+// so it blocks only if the fetcher returns more events than the batch size,
+// and reports false if ctx is cancelled meanwhile.
+func (c *Consumer) dispatch(ctx context.Context, queues []chan job, progress *progress, events []events.Event) bool {
 	for _, event := range events {
-		log.Printf("got new event: %s", event.Text)
+		j := job{event: event, seq: progress.add(event.ID)}
 
-		if err := c.processor.Process(ctx, event); err != nil {
-			log.Printf("can't handle event: %s", err.Error())
+		select {
+		case queues[shard(event.ChatID, len(queues))] <- j:
+		case <-ctx.Done():
+			return false
+		}
+	}
 
-			continue
+	return true
+}
+
+// handleEvent processes the event and reports whether it is done with. An
+// event interrupted by shutdown is not: it is received again after a
+// restart.
+func (c *Consumer) handleEvent(ctx context.Context, event events.Event) bool {
+	log.Printf("got new event: %s", event.Text)
+
+	if err := c.processor.Process(ctx, event); err != nil {
+		if ctx.Err() != nil {
+			return false
 		}
+
+		log.Printf("can't handle event: %s", err.Error())
+	}
+
+	return true
+}
+
+// commit persists the position after the events handled without a gap.
+func (c *Consumer) commit(ctx context.Context, progress *progress) error {
+	committer, ok := c.fetcher.(events.Committer)
+	if !ok {
+		return nil
+	}
+
+	id, ok := progress.handled()
+	if !ok {
+		return nil
+	}
+
+	return committer.Commit(ctx, id)
+}
+
+func shard(chatID int, n int) int {
+	if chatID < 0 {
+		chatID = -chatID
 	}
 
-	return nil
+	return chatID % n
 }
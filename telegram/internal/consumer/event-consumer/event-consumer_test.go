@@ -0,0 +1,162 @@
+package event_consumer
+
+import (
+	"api/internal/events"
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeFetcher returns the batches in order, then nothing, and records the
+// commits.
+type fakeFetcher struct {
+	mu        sync.Mutex
+	batches   [][]events.Event
+	committed []int
+}
+
+func (f *fakeFetcher) Fetch(ctx context.Context, limit int) ([]events.Event, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.batches) == 0 {
+		return nil, nil
+	}
+
+	batch := f.batches[0]
+	f.batches = f.batches[1:]
+
+	return batch, nil
+}
+
+func (f *fakeFetcher) Commit(ctx context.Context, id int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.committed = append(f.committed, id)
+
+	return nil
+}
+
+func (f *fakeFetcher) lastCommit() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.committed) == 0 {
+		return 0
+	}
+
+	return f.committed[len(f.committed)-1]
+}
+
+// fakeProcessor blocks the events of slowChat until release is closed and
+// reports every handled event id.
+type fakeProcessor struct {
+	slowChat int
+	release  chan struct{}
+	handled  chan int
+}
+
+func (p *fakeProcessor) Process(ctx context.Context, e events.Event) error {
+	if e.ChatID == p.slowChat {
+		select {
+		case <-p.release:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	p.handled <- e.ID
+
+	return nil
+}
+
+func newFakes(batches ...[]events.Event) (*fakeFetcher, *fakeProcessor) {
+	return &fakeFetcher{batches: batches}, &fakeProcessor{
+		slowChat: 1,
+		release:  make(chan struct{}),
+		handled:  make(chan int, 10),
+	}
+}
+
+func waitHandled(t *testing.T, p *fakeProcessor, want int) {
+	t.Helper()
+
+	select {
+	case id := <-p.handled:
+		if id != want {
+			t.Fatalf("handled event %d, want %d", id, want)
+		}
+	case <-time.After(3 * time.Second):
+		t.Fatalf("event %d was not handled", want)
+	}
+}
+
+func TestSlowChatDoesNotStallOtherChats(t *testing.T) {
+	fetcher, processor := newFakes(
+		[]events.Event{{ID: 1, ChatID: 1}, {ID: 2, ChatID: 2}},
+		[]events.Event{{ID: 3, ChatID: 2}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = New(fetcher, processor, 10, 2).Start(ctx)
+	}()
+
+	// The second batch is fetched and handled while chat 1 is still busy.
+	waitHandled(t, processor, 2)
+	waitHandled(t, processor, 3)
+
+	if got := fetcher.lastCommit(); got != 0 {
+		t.Fatalf("committed %d while event 1 is in work", got)
+	}
+
+	close(processor.release)
+	waitHandled(t, processor, 1)
+
+	deadline := time.Now().Add(3 * time.Second)
+	for fetcher.lastCommit() != 3 {
+		if time.Now().After(deadline) {
+			t.Fatalf("committed %d, want 3", fetcher.lastCommit())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func TestShutdownLeavesUnhandledEventsUncommitted(t *testing.T) {
+	fetcher, processor := newFakes(
+		[]events.Event{{ID: 1, ChatID: 2}, {ID: 2, ChatID: 1}, {ID: 3, ChatID: 2}},
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = New(fetcher, processor, 10, 2).Start(ctx)
+	}()
+
+	waitHandled(t, processor, 1)
+	waitHandled(t, processor, 3)
+
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(3 * time.Second):
+		t.Fatal("consumer didn't stop")
+	}
+
+	// Event 2 was interrupted, so events 2 and 3 are received again.
+	if got := fetcher.lastCommit(); got != 1 {
+		t.Fatalf("committed %d, want 1", got)
+	}
+}
@@ -0,0 +1,74 @@
+package event_consumer
+
+import "sync"
+
+// progress tracks which of the fetched events are handled. Workers finish
+// events out of order, the position is committed only up to the first event
+// that is still in work.
+type progress struct {
+	mu sync.Mutex
+	// ids are the event ids in fetch order, starting with seq first.
+	ids   []int
+	first int
+	done  map[int]bool
+	// last is the id of the last event of the handled prefix not committed
+	// yet.
+	last    int
+	hasLast bool
+	// moved gets a value when the handled prefix grows.
+	moved chan struct{}
+}
+
+func newProgress() *progress {
+	return &progress{done: make(map[int]bool), moved: make(chan struct{}, 1)}
+}
+
+// add registers a fetched event and returns its sequence number.
+func (p *progress) add(id int) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.ids = append(p.ids, id)
+
+	return p.first + len(p.ids) - 1
+}
+
+// finish marks the event with the sequence number as handled.
+func (p *progress) finish(seq int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.done[seq] = true
+
+	for len(p.ids) > 0 && p.done[p.first] {
+		delete(p.done, p.first)
+		p.last, p.hasLast = p.ids[0], true
+		p.ids = p.ids[1:]
+		p.first++
+	}
+
+	if p.hasLast {
+		select {
+		case p.moved <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// advanced returns a channel that receives when the handled prefix has grown
+// since the last receive.
+func (p *progress) advanced() <-chan struct{} {
+	return p.moved
+}
+
+// handled returns the id of the last event handled together with every
+// event fetched before it, once per new position.
+func (p *progress) handled() (int, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id, ok := p.last, p.hasLast
+	p.hasLast = false
+
+	return id, ok
+}
@@ -0,0 +1,224 @@
+package telegram
+
+import (
+	"api/internal/clients/blogator"
+	"api/internal/clients/rabbitmq"
+	"api/internal/clients/telegram"
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Callback data of the broadcast confirmation buttons.
+const (
+	broadcastSendCallback   = "broadcast:send"
+	broadcastCancelCallback = "broadcast:cancel"
+)
+
+// broadcastProgressEvery is how often the admin's progress message of a
+// broadcast is updated.
+const broadcastProgressEvery = 10 * time.Second
+
+// isAdmin reports whether the chat may use the admin commands.
+func (p *Processor) isAdmin(chatID int) bool {
+	return slices.Contains(p.admins, chatID)
+}
+
+func (p *Processor) stats(ctx context.Context, req request) error {
+	stats, err := p.db.Stats(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get stats: %w", err)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(i18n.T(req.lang, i18n.StatsHeader))
+	for _, s := range stats {
+		b.WriteString("\n" + i18n.T(req.lang, i18n.StatsLine, s.Messenger, s.Active, s.Inactive))
+	}
+
+	return p.reply(ctx, req.Meta, b.String())
+}
+
+// broadcast keeps the text until the admin confirms it with a button.
+func (p *Processor) broadcast(ctx context.Context, req request) error {
+	if req.rawArgs == "" {
+		return p.reply(ctx, req.Meta, i18n.T(req.lang, i18n.BroadcastUsage))
+	}
+
+	p.mu.Lock()
+	p.broadcasts[req.ChatID] = req.rawArgs
+	p.mu.Unlock()
+
+	return p.reply(ctx, req.Meta, i18n.T(req.lang, i18n.BroadcastConfirm, req.rawArgs),
+		telegram.WithReplyMarkup(broadcastKeyboard(req.lang)),
+	)
+}
+
+func broadcastKeyboard(lang i18n.Lang) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: i18n.T(lang, i18n.BtnSend), CallbackData: broadcastSendCallback},
+			{Text: i18n.T(lang, i18n.BtnCancel), CallbackData: broadcastCancelCallback},
+		}},
+	}
+}
+
+// confirmBroadcast handles the confirmation buttons and returns the text to
+// answer the callback with. A confirmed broadcast is sent in the background,
+// one at a time; a broadcast confirmed while another one is being sent stays
+// pending.
+func (p *Processor) confirmBroadcast(ctx context.Context, chatID int, lang i18n.Lang, data string) string {
+	if !p.isAdmin(chatID) {
+		return i18n.T(lang, i18n.UnknownCommand)
+	}
+
+	p.mu.Lock()
+	if data == broadcastSendCallback && p.broadcasting {
+		p.mu.Unlock()
+		return i18n.T(lang, i18n.BroadcastRunning)
+	}
+
+	text, ok := p.broadcasts[chatID]
+	delete(p.broadcasts, chatID)
+
+	start := ok && data == broadcastSendCallback
+	if start {
+		p.broadcasting = true
+	}
+	p.mu.Unlock()
+
+	switch {
+	case !ok:
+		return i18n.T(lang, i18n.BroadcastNothing)
+	case !start:
+		return i18n.T(lang, i18n.BroadcastCancelled)
+	}
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.broadcasting = false
+			p.mu.Unlock()
+		}()
+
+		if err := p.sendBroadcast(ctx, chatID, lang, text); err != nil {
+			log.Printf("Error sending broadcast: %v", err)
+		}
+	}()
+
+	return i18n.T(lang, i18n.BroadcastStarted)
+}
+
+// sendBroadcast sends text to every active subscriber. The admin sees the
+// progress in a message that is updated while the broadcast goes and gets
+// the result at the end, also when the broadcast is interrupted by shutdown.
+func (p *Processor) sendBroadcast(ctx context.Context, chatID int, lang i18n.Lang, text string) error {
+	chatIDs, err := p.db.AllUsers(ctx, messangerType)
+	if err != nil {
+		return fmt.Errorf("failed to get subscribers: %w", err)
+	}
+
+	log.Printf("Broadcasting to %d chats", len(chatIDs))
+
+	threads := p.chatThreads(ctx)
+
+	progressID, err := p.tg.SendMessageWithID(ctx, chatID, i18n.T(lang, i18n.BroadcastProgress, 0, len(chatIDs)))
+	if err != nil {
+		log.Printf("Error sending broadcast progress to chat %d: %v", chatID, err)
+	}
+	reported := time.Now()
+
+	sent := 0
+	for i, id := range chatIDs {
+		if ctx.Err() != nil {
+			break
+		}
+
+		if err := p.tg.SendMessage(ctx, id, text, telegram.InThread(threads[id])); err != nil {
+			log.Printf("Error sending broadcast to chat %d: %v", id, err)
+			p.deactivateUnavailable(ctx, id, err)
+		} else {
+			sent++
+		}
+
+		if progressID != 0 && time.Since(reported) >= broadcastProgressEvery {
+			progress := i18n.T(lang, i18n.BroadcastProgress, i+1, len(chatIDs))
+			if _, err := p.tg.EditMessageText(ctx, chatID, []int{progressID}, progress); err != nil {
+				log.Printf("Error updating broadcast progress in chat %d: %v", chatID, err)
+			}
+			reported = time.Now()
+		}
+	}
+
+	result := i18n.N(lang, i18n.BroadcastDone, len(chatIDs), sent, len(chatIDs))
+	if ctx.Err() != nil {
+		result = i18n.T(lang, i18n.BroadcastStopped, sent, len(chatIDs))
+	}
+
+	// The result is reported even when the broadcast was stopped by shutdown.
+	reportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	return p.tg.SendMessage(reportCtx, chatID, result)
+}
+
+// preview sends the next queued post to the admin as subscribers will see
+// it. While the consumer keeps the queue drained the latest post from
+// blogator is shown instead.
+func (p *Processor) preview(ctx context.Context, req request) error {
+	ps, queued, err := p.nextPost(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get post to preview: %w", err)
+	}
+	if ps.Link == "" {
+		return p.reply(ctx, req.Meta, i18n.T(req.lang, i18n.NoPosts))
+	}
+
+	header := i18n.PreviewLatest
+	if queued {
+		header = i18n.PreviewQueued
+	}
+
+	if err := p.reply(ctx, req.Meta, i18n.T(req.lang, header)); err != nil {
+		return err
+	}
+
+	_, err = p.sendPost(ctx, req.ChatID, ps.ImageURL, postCard(req.lang, ps),
+		telegram.WithParseMode(telegram.ParseModeHTML),
+		telegram.WithReplyMarkup(postKeyboard(req.lang, ps.Link)),
+		telegram.InThread(req.ThreadID),
+	)
+
+	return err
+}
+
+// nextPost returns the first post of the queue or, if it is empty, the
+// latest post from blogator. queued reports where the post came from.
+func (p *Processor) nextPost(ctx context.Context) (ps rabbitmq.DataItem, queued bool, err error) {
+	post, ok, err := p.queue.Peek()
+	if err != nil {
+		log.Printf("can't peek the queue: %v", err)
+	}
+	if ok && len(post.Data) > 0 {
+		return post.Data[0], true, nil
+	}
+
+	items, err := p.ator.GetNewItems(ctx, time.Now().UTC().AddDate(0, 0, -maxLatestDays))
+	if err != nil {
+		return rabbitmq.DataItem{}, false, err
+	}
+	if len(items) == 0 {
+		return rabbitmq.DataItem{}, false, nil
+	}
+
+	latest := slices.MaxFunc(items, func(a, b blogator.DataItem) int {
+		return a.CollectedDate.Compare(b.CollectedDate)
+	})
+
+	return postItem(latest), false, nil
+}
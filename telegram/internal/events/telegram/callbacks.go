@@ -0,0 +1,74 @@
+package telegram
+
+import (
+	"api/internal/events"
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+	"strings"
+)
+
+func (p *Processor) processCallback(ctx context.Context, event events.Event) error {
+	meta, err := meta(event)
+	if err != nil {
+		return fmt.Errorf("can't process callback: %w", err)
+	}
+
+	log.Printf("got callback '%s' from '%s' with chatID: %d", event.Text, meta.Username, meta.ChatID)
+
+	var answer string
+
+	lang := p.language(ctx, meta)
+
+	switch data := event.Text; {
+	case strings.HasPrefix(data, latestCallbackPrefix):
+		q, parseErr := parseLatestCallback(data)
+		if parseErr != nil {
+			answer = i18n.T(lang, i18n.UnknownCommand)
+			break
+		}
+		err = p.Post(ctx, meta, lang, q)
+	case data == subscribeCallback, data == unsubscribeCallback, strings.HasPrefix(data, muteCallbackPrefix):
+		answer, err = p.manageSubscription(ctx, meta, lang, data)
+	case data == broadcastSendCallback, data == broadcastCancelCallback:
+		answer = p.confirmBroadcast(ctx, meta.ChatID, lang, data)
+	default:
+		answer = i18n.T(lang, i18n.UnknownCommand)
+	}
+
+	if ackErr := p.tg.AnswerCallbackQuery(ctx, meta.CallbackID, answer); ackErr != nil {
+		log.Printf("can't answer callback query: %v", ackErr)
+	}
+
+	if err != nil {
+		return fmt.Errorf("can't process callback: %w", err)
+	}
+
+	return nil
+}
+
+// manageSubscription handles the buttons that change the chat's subscription
+// and returns the text to answer the callback with.
+func (p *Processor) manageSubscription(ctx context.Context, meta Meta, lang i18n.Lang, data string) (string, error) {
+	allowed, err := p.isChatAdmin(ctx, meta)
+	if err != nil {
+		return "", fmt.Errorf("can't check chat admin: %w", err)
+	}
+	if !allowed {
+		return i18n.T(lang, i18n.AdminsOnly), nil
+	}
+
+	switch {
+	case data == subscribeCallback:
+		return "", p.subscribe(ctx, meta, lang)
+	case data == unsubscribeCallback:
+		return "", p.unsubscribe(ctx, meta, lang)
+	default:
+		source := strings.TrimPrefix(data, muteCallbackPrefix)
+		if err := p.db.MuteSource(ctx, meta.ChatID, source); err != nil {
+			return "", err
+		}
+		return i18n.T(lang, i18n.SourceMuted, source), nil
+	}
+}
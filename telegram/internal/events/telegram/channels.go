@@ -0,0 +1,72 @@
+package telegram
+
+import (
+	"api/internal/clients/db"
+	"api/internal/clients/rabbitmq"
+	"api/internal/clients/telegram"
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+	"strconv"
+)
+
+// sendToChannels posts to every configured channel with the channel template.
+func (p *Processor) sendToChannels(ctx context.Context, ps rabbitmq.DataItem) []db.Delivery {
+	if len(p.channels) == 0 {
+		return nil
+	}
+
+	text := channelCard(ps)
+
+	deliveries := make([]db.Delivery, 0, len(p.channels))
+	for _, channel := range p.channels {
+		chatID, err := p.channelID(ctx, channel)
+		if err != nil {
+			log.Printf("Error resolving channel %s: %v", channel, err)
+			deliveries = append(deliveries, delivery(ps, channel, db.TargetChannel, sentPost{}, err))
+			continue
+		}
+
+		log.Printf("Sending post to channel %s: %s", channel, ps.Title)
+
+		sent, err := p.sendPost(ctx, chatID, ps.ImageURL, text,
+			telegram.WithParseMode(telegram.ParseModeHTML),
+			telegram.WithReplyMarkup(channelKeyboard(i18n.Default, ps.Link)),
+		)
+		if err != nil {
+			log.Printf("Error sending message to channel %s: %v", channel, err)
+		}
+
+		deliveries = append(deliveries, delivery(ps, strconv.Itoa(chatID), db.TargetChannel, sent, err))
+	}
+
+	return deliveries
+}
+
+// channelID resolves a configured channel, an @username or a numeric id, to
+// its chat id. Resolved usernames are cached.
+func (p *Processor) channelID(ctx context.Context, channel string) (int, error) {
+	if id, err := strconv.Atoi(channel); err == nil {
+		return id, nil
+	}
+
+	p.mu.Lock()
+	id, ok := p.channelIDs[channel]
+	p.mu.Unlock()
+
+	if ok {
+		return id, nil
+	}
+
+	chat, err := p.tg.GetChat(ctx, channel)
+	if err != nil {
+		return 0, fmt.Errorf("can't get channel: %w", err)
+	}
+
+	p.mu.Lock()
+	p.channelIDs[channel] = chat.ID
+	p.mu.Unlock()
+
+	return chat.ID, nil
+}
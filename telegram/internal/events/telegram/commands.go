@@ -1,11 +1,13 @@
 package telegram
 
 import (
+	"api/internal/clients/telegram"
 	"context"
 	"fmt"
+	"i18n"
 	"log"
 	"strings"
-	"time"
+	"unicode"
 )
 
 const (
@@ -13,94 +15,180 @@ const (
 	UnsubscribeCmd = "/unsubscribe"
 	HelpCmd        = "/help"
 	StartCmd       = "/start"
+	LatestCmd      = "/latest"
+	LangCmd        = "/lang"
+	StatsCmd       = "/stats"
+	BroadcastCmd   = "/broadcast"
+	PreviewCmd     = "/preview"
 )
 
-func (p *Processor) doCmd(ctx context.Context, text string, chatID int, username string) error {
+// request is a command received by the bot.
+type request struct {
+	Meta
+	args []string
+	// rawArgs is the text after the command with its line breaks kept.
+	rawArgs string
+	lang    i18n.Lang
+}
+
+func (p *Processor) doCmd(ctx context.Context, text string, meta Meta) error {
 	text = strings.TrimSpace(text)
 
-	log.Printf("got new command '%s' from '%s' with chatID: %d", text, username, chatID)
+	log.Printf("got new command '%s' from '%s' with chatID: %d", text, meta.Username, meta.ChatID)
 
-	switch text {
-	case SubscribeCmd:
-		return p.subscribe(ctx, chatID)
-	case UnsubscribeCmd:
-		return p.unsubscribe(ctx, chatID)
-	case HelpCmd:
-		return p.sendHelp(ctx, chatID)
-	case StartCmd:
-		return p.sendHello(ctx, chatID)
-	default:
-		return p.tg.SendMessage(ctx, chatID, msgUnknownCommand)
+	name, mention, args := parseCommand(text)
+	if mention != "" && !p.isMe(ctx, mention) {
+		return nil
 	}
-}
 
-func (p *Processor) subscribe(ctx context.Context, chatID int) error {
-	res, err := p.db.ChatExists(ctx, chatID)
-	if err != nil {
-		return fmt.Errorf("can't check if chat exists: %w", err)
+	lang := p.language(ctx, meta)
+
+	cmd, ok := p.command(name)
+	// Admin commands are hidden from everyone else.
+	if ok && cmd.admin && !p.isAdmin(meta.ChatID) {
+		ok = false
 	}
-	if res {
-		return p.tg.SendMessage(ctx, chatID, msgAlreadySubscribed)
+	if !ok {
+		// In groups a command without a mention may be meant for another bot.
+		if isGroup(meta.ChatType) && mention == "" {
+			return nil
+		}
+		return p.reply(ctx, meta, i18n.T(lang, i18n.UnknownCommand))
 	}
 
-	if err = p.db.SaveUser(ctx, chatID); err != nil {
-		return fmt.Errorf("can't save user: %w", err)
+	if cmd.groupAdmin {
+		allowed, err := p.isChatAdmin(ctx, meta)
+		if err != nil {
+			return fmt.Errorf("can't check chat admin: %w", err)
+		}
+		if !allowed {
+			return p.reply(ctx, meta, i18n.T(lang, i18n.AdminsOnly))
+		}
 	}
-	return p.tg.SendMessage(ctx, chatID, msgSubscribed)
+
+	return cmd.handle(p, ctx, request{Meta: meta, args: args, rawArgs: rawArgs(text), lang: lang})
 }
 
-func (p *Processor) unsubscribe(ctx context.Context, chatID int) error {
-	exists, err := p.db.ChatExists(ctx, chatID)
-	if err != nil {
-		return fmt.Errorf("can't check if chat exists: %w", err)
+// parseCommand splits "/cmd@BotName arg1 arg2" into the lower-cased command
+// name, the mentioned bot and the arguments.
+func parseCommand(text string) (name string, mention string, args []string) {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return "", "", nil
 	}
 
-	if !exists {
-		return p.tg.SendMessage(ctx, chatID, "You are already unsubscribed!")
+	name = fields[0]
+	if i := strings.Index(name, "@"); i >= 0 {
+		name, mention = name[:i], name[i+1:]
 	}
 
-	if err := p.db.DeleteUser(ctx, chatID); err != nil {
-		return fmt.Errorf("can't unsubscribe: %w", err)
+	return strings.ToLower(name), mention, fields[1:]
+}
+
+// rawArgs returns the text after the command.
+func rawArgs(text string) string {
+	i := strings.IndexFunc(text, unicode.IsSpace)
+	if i < 0 {
+		return ""
 	}
 
-	return p.tg.SendMessage(ctx, chatID, msgUnsubscribedSuccess)
+	return strings.TrimSpace(text[i:])
 }
 
-func (p *Processor) Post(ctx context.Context, chatID int) (err error) {
-	twoDaysAgo := time.Now().UTC().AddDate(0, 0, -2)
-	log.Printf("Fetching posts since %v", twoDaysAgo)
+// isMe reports whether mention is the bot's username. If the username can't
+// be fetched every mention is accepted.
+func (p *Processor) isMe(ctx context.Context, mention string) bool {
+	username := p.botUsername(ctx)
 
-	items, err := p.ator.GetNewItems(ctx, twoDaysAgo)
-	if err != nil {
-		return fmt.Errorf("can't get new posts: %w", err)
+	return username == "" || strings.EqualFold(username, mention)
+}
+
+func (p *Processor) botUsername(ctx context.Context) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.username == "" {
+		me, err := p.tg.GetMe(ctx)
+		if err != nil {
+			log.Printf("can't get bot username: %v", err)
+			return ""
+		}
+		p.username = me.Username
+	}
+
+	return p.username
+}
+
+// isChatAdmin reports whether the sender may manage the chat's subscription:
+// anyone in a private chat, only administrators in groups.
+func (p *Processor) isChatAdmin(ctx context.Context, meta Meta) (bool, error) {
+	if !isGroup(meta.ChatType) {
+		return true, nil
 	}
 
-	if len(items) == 0 {
-		return p.tg.SendMessage(ctx, chatID, msgNoPosts)
+	// Anonymous administrators send messages on behalf of the group itself.
+	if meta.SenderChatID == meta.ChatID {
+		return true, nil
 	}
 
-	log.Printf("Got %d new posts", len(items))
+	member, err := p.tg.GetChatMember(ctx, meta.ChatID, meta.UserID)
+	if err != nil {
+		return false, err
+	}
 
-	for _, item := range items {
-		text := fmt.Sprintf(`
-📰 %s
+	return member.Status == telegram.MemberCreator || member.Status == telegram.MemberAdministrator, nil
+}
 
-🔗 %s
-`, item.Title, item.Link)
+func isGroup(chatType string) bool {
+	return chatType == telegram.ChatGroup || chatType == telegram.ChatSupergroup
+}
 
-		log.Printf("Sending message to chat %d: %s", chatID, item.Title)
-		if err := p.tg.SendMessage(ctx, chatID, text); err != nil {
-			log.Printf("Error sending message: %v", err)
-			return fmt.Errorf("can't send message: %w", err)
+// subscribe subscribes the chat. In forums the subscription follows the
+// topic it was made in, so subscribing again moves the posts there.
+func (p *Processor) subscribe(ctx context.Context, meta Meta, lang i18n.Lang) error {
+	if !meta.IsForum {
+		res, err := p.db.ChatExists(ctx, meta.ChatID)
+		if err != nil {
+			return fmt.Errorf("can't check if chat exists: %w", err)
 		}
+		if res {
+			return p.reply(ctx, meta, i18n.T(lang, i18n.AlreadySubscribed))
+		}
+	}
+
+	if err := p.db.SaveUser(ctx, meta.ChatID, meta.ThreadID); err != nil {
+		return fmt.Errorf("can't save user: %w", err)
+	}
+	return p.reply(ctx, meta, i18n.T(lang, i18n.Subscribed))
+}
+
+func (p *Processor) unsubscribe(ctx context.Context, meta Meta, lang i18n.Lang) error {
+	exists, err := p.db.ChatExists(ctx, meta.ChatID)
+	if err != nil {
+		return fmt.Errorf("can't check if chat exists: %w", err)
+	}
+
+	if !exists {
+		return p.reply(ctx, meta, i18n.T(lang, i18n.AlreadyUnsubscribed))
 	}
-	return nil
+
+	if err := p.db.DeleteUser(ctx, meta.ChatID); err != nil {
+		return fmt.Errorf("can't unsubscribe: %w", err)
+	}
+
+	return p.reply(ctx, meta, i18n.T(lang, i18n.Unsubscribed))
+}
+
+func (p *Processor) sendHelp(ctx context.Context, meta Meta, lang i18n.Lang) error {
+	return p.reply(ctx, meta, p.helpText(lang, p.isAdmin(meta.ChatID)), telegram.WithReplyMarkup(subscriptionKeyboard(lang)))
 }
 
-func (p *Processor) sendHelp(ctx context.Context, chatID int) error {
-	return p.tg.SendMessage(ctx, chatID, msgHelp)
+func (p *Processor) sendHello(ctx context.Context, meta Meta, lang i18n.Lang) error {
+	return p.reply(ctx, meta, i18n.T(lang, i18n.Hello)+p.helpText(lang, p.isAdmin(meta.ChatID)), telegram.WithReplyMarkup(subscriptionKeyboard(lang)))
 }
 
-func (p *Processor) sendHello(ctx context.Context, chatID int) error {
-	return p.tg.SendMessage(ctx, chatID, msgHello)
+// reply sends text to the chat of the message being answered, in its forum
+// topic if it has one.
+func (p *Processor) reply(ctx context.Context, meta Meta, text string, opts ...telegram.SendOption) error {
+	return p.tg.SendMessage(ctx, meta.ChatID, text, append(opts, telegram.InThread(meta.ThreadID))...)
 }
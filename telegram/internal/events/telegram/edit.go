@@ -0,0 +1,84 @@
+package telegram
+
+import (
+	"api/internal/clients/db"
+	"api/internal/clients/rabbitmq"
+	"api/internal/clients/telegram"
+	"context"
+	"i18n"
+	"log"
+	"strconv"
+	"time"
+)
+
+// editDelivered brings the messages a post was already delivered as up to
+// date with its new version. A post that hasn't changed since is not sent
+// again.
+func (p *Processor) editDelivered(ctx context.Context, ps rabbitmq.DataItem, delivered []db.Delivery) []db.Delivery {
+	// Postgres keeps microseconds, so a post read back from the deliveries
+	// looks older than the same post from the queue.
+	updated := ps.UpdatedDate.Truncate(time.Microsecond)
+
+	var deliveries []db.Delivery
+	for _, d := range delivered {
+		if !updated.After(d.UpdatedDate) {
+			continue
+		}
+
+		chatID, err := strconv.Atoi(d.ChatID)
+		if err != nil {
+			log.Printf("Error parsing chat id %q of post %d: %v", d.ChatID, ps.ID, err)
+			continue
+		}
+
+		log.Printf("Editing post %d in chat %d: %s", ps.ID, chatID, ps.Title)
+
+		sent, err := p.editPost(ctx, chatID, d.Target, ps, sentPost{messageIDs: d.MessageIDs, caption: d.Caption})
+		if err != nil {
+			log.Printf("Error editing messages %v in chat %d: %v", d.MessageIDs, chatID, err)
+			if d.Target == db.TargetChat {
+				p.deactivateUnavailable(ctx, chatID, err)
+			}
+		}
+
+		e := delivery(ps, d.ChatID, d.Target, sent, err)
+		e.Status = db.DeliveryEdited
+		if err != nil {
+			e.Status = db.DeliveryFailed
+		}
+		deliveries = append(deliveries, e)
+	}
+
+	if len(deliveries) == 0 {
+		log.Printf("Post %d is already delivered", ps.ID)
+	}
+
+	return deliveries
+}
+
+// editPost replaces the messages with the post rendered the way it is sent to
+// the target and returns the messages the post is in now.
+func (p *Processor) editPost(ctx context.Context, chatID int, target string, ps rabbitmq.DataItem, sent sentPost) (sentPost, error) {
+	text := channelCard(ps)
+	markup := channelKeyboard(i18n.Default, ps.Link)
+
+	if target == db.TargetChat {
+		lang := p.chatLanguage(ctx, chatID)
+		text = postCard(lang, ps)
+		markup = postKeyboard(lang, ps.Link)
+	}
+
+	opts := []telegram.SendOption{
+		telegram.WithParseMode(telegram.ParseModeHTML),
+		telegram.WithReplyMarkup(markup),
+	}
+
+	var err error
+	if sent.caption {
+		sent.messageIDs, err = p.tg.EditMessageCaption(ctx, chatID, sent.messageIDs, text, opts...)
+	} else {
+		sent.messageIDs, err = p.tg.EditMessageText(ctx, chatID, sent.messageIDs, text, opts...)
+	}
+
+	return sent, err
+}
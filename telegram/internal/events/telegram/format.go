@@ -0,0 +1,61 @@
+package telegram
+
+import (
+	"api/internal/clients/rabbitmq"
+	"api/internal/clients/telegram"
+	"i18n"
+	"strings"
+	"unicode"
+)
+
+// postCard renders a post as an HTML message: bold title, summary, the
+// editor's comment and a link hidden behind text.
+func postCard(lang i18n.Lang, ps rabbitmq.DataItem) string {
+	var b strings.Builder
+
+	b.WriteString("📰 <b>" + telegram.EscapeHTML(ps.Title) + "</b>")
+
+	if summary := strings.TrimSpace(ps.Summary); summary != "" {
+		b.WriteString("\n\n" + telegram.EscapeHTML(summary))
+	}
+
+	if comment := strings.TrimSpace(ps.Comment); comment != "" {
+		b.WriteString("\n\n🗣 <i>" + telegram.EscapeHTML(comment) + "</i>")
+	}
+
+	b.WriteString("\n\n🔗 <a href=\"" + telegram.EscapeHTML(ps.Link) + "\">" + i18n.T(lang, i18n.ReadPost) + "</a>")
+
+	return b.String()
+}
+
+// channelCard renders a post for channels, where it is read as a feed item:
+// the title links to the post and the site is shown as a hashtag.
+func channelCard(ps rabbitmq.DataItem) string {
+	var b strings.Builder
+
+	b.WriteString("<b><a href=\"" + telegram.EscapeHTML(ps.Link) + "\">" + telegram.EscapeHTML(ps.Title) + "</a></b>")
+
+	if summary := strings.TrimSpace(ps.Summary); summary != "" {
+		b.WriteString("\n\n" + telegram.EscapeHTML(summary))
+	}
+
+	if comment := strings.TrimSpace(ps.Comment); comment != "" {
+		b.WriteString("\n\n🗣 " + telegram.EscapeHTML(comment))
+	}
+
+	if tag := sourceTag(postSource(ps.Link)); tag != "" {
+		b.WriteString("\n\n#" + tag)
+	}
+
+	return b.String()
+}
+
+// sourceTag turns a site name into a hashtag: "habr.com" becomes "habr_com".
+func sourceTag(source string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return '_'
+	}, source)
+}
@@ -0,0 +1,156 @@
+package telegram
+
+import (
+	"api/internal/clients/blogator"
+	"api/internal/clients/rabbitmq"
+	"api/internal/clients/telegram"
+	"api/internal/events"
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// inlinePageSize is the number of results in one answer, the next ones
+	// are requested with next_offset as the user scrolls.
+	inlinePageSize = 20
+	// inlineCacheTime is how long, in seconds, Telegram may cache an answer.
+	inlineCacheTime = 300
+	// archiveTTL is how long the recent posts are searched before they are
+	// fetched from blogator again.
+	archiveTTL = time.Minute
+)
+
+// processInlineQuery answers "@bot query" with the recent posts whose title
+// contains every word of the query, newest first.
+func (p *Processor) processInlineQuery(ctx context.Context, event events.Event) error {
+	meta, err := meta(event)
+	if err != nil {
+		return fmt.Errorf("can't process inline query: %w", err)
+	}
+
+	log.Printf("got inline query '%s' from '%s'", event.Text, meta.Username)
+
+	items, err := p.recentPosts(ctx)
+	if err != nil {
+		return fmt.Errorf("can't process inline query: %w", err)
+	}
+
+	found := searchPosts(items, event.Text)
+
+	// A malformed offset starts from the first page.
+	offset, _ := strconv.Atoi(meta.Offset)
+	offset = min(max(offset, 0), len(found))
+	end := min(offset+inlinePageSize, len(found))
+
+	lang := p.language(ctx, meta)
+
+	answer := telegram.InlineQueryAnswer{
+		Results:   make([]telegram.InlineQueryResultArticle, 0, end-offset),
+		CacheTime: inlineCacheTime,
+		// Results are rendered in the user's language.
+		IsPersonal: true,
+	}
+	for _, item := range found[offset:end] {
+		answer.Results = append(answer.Results, inlineResult(lang, item))
+	}
+	if end < len(found) {
+		answer.NextOffset = strconv.Itoa(end)
+	}
+
+	if err := p.tg.AnswerInlineQuery(ctx, meta.QueryID, answer); err != nil {
+		return fmt.Errorf("can't process inline query: %w", err)
+	}
+
+	return nil
+}
+
+func inlineResult(lang i18n.Lang, item blogator.DataItem) telegram.InlineQueryResultArticle {
+	ps := postItem(item)
+
+	res := telegram.NewInlineQueryResultArticle(strconv.FormatInt(ps.ID, 10), ps.Title, postCard(lang, ps), telegram.ParseModeHTML)
+	res.Description = ps.CollectedDate.Format("02.01.2006")
+	if source := postSource(ps.Link); source != "" {
+		res.Description = source + " · " + res.Description
+	}
+	res.URL = ps.Link
+
+	// The post is shared into chats of other people, so it gets no buttons
+	// that change their subscription.
+	markup := channelKeyboard(lang, ps.Link)
+	res.ReplyMarkup = &markup
+
+	return res
+}
+
+// recentPosts returns the posts of the last maxLatestDays days, newest first.
+// They are kept for archiveTTL, as inline queries arrive on every keystroke.
+func (p *Processor) recentPosts(ctx context.Context) ([]blogator.DataItem, error) {
+	p.mu.Lock()
+	items, fetched := p.archive, p.archiveAt
+	p.mu.Unlock()
+
+	if time.Since(fetched) < archiveTTL {
+		return items, nil
+	}
+
+	items, err := p.ator.GetNewItems(ctx, time.Now().UTC().AddDate(0, 0, -maxLatestDays))
+	if err != nil {
+		return nil, fmt.Errorf("can't get recent posts: %w", err)
+	}
+
+	slices.SortFunc(items, func(a, b blogator.DataItem) int {
+		return b.CollectedDate.Compare(a.CollectedDate)
+	})
+
+	p.mu.Lock()
+	p.archive, p.archiveAt = items, time.Now()
+	p.mu.Unlock()
+
+	return items, nil
+}
+
+// searchPosts returns the posts whose title contains every word of query.
+// An empty query matches every post.
+func searchPosts(items []blogator.DataItem, query string) []blogator.DataItem {
+	words := strings.Fields(strings.ToLower(query))
+	if len(words) == 0 {
+		return items
+	}
+
+	var found []blogator.DataItem
+	for _, item := range items {
+		title := strings.ToLower(item.Title)
+
+		matches := true
+		for _, word := range words {
+			if !strings.Contains(title, word) {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			found = append(found, item)
+		}
+	}
+
+	return found
+}
+
+// postItem converts a post from blogator to the form posts come in from the
+// queue.
+func postItem(item blogator.DataItem) rabbitmq.DataItem {
+	return rabbitmq.DataItem{
+		ID:            item.ID,
+		Title:         item.Title,
+		Link:          item.Link,
+		UpdatedDate:   item.UpdatedDate,
+		CollectedDate: item.CollectedDate,
+	}
+}
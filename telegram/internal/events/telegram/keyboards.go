@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"api/internal/clients/telegram"
+	"i18n"
+	"net/url"
+	"strings"
+)
+
+// Callback data sent by the inline keyboard buttons.
+const (
+	subscribeCallback   = "subscribe"
+	unsubscribeCallback = "unsubscribe"
+	muteCallbackPrefix  = "mute:"
+
+	// maxCallbackData is the Telegram limit for callback_data in bytes.
+	maxCallbackData = 64
+)
+
+func subscriptionKeyboard(lang i18n.Lang) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: i18n.T(lang, i18n.BtnSubscribe), CallbackData: subscribeCallback},
+			{Text: i18n.T(lang, i18n.BtnUnsubscribe), CallbackData: unsubscribeCallback},
+		}},
+	}
+}
+
+func postKeyboard(lang i18n.Lang, link string) telegram.InlineKeyboardMarkup {
+	row := []telegram.InlineKeyboardButton{
+		{Text: i18n.T(lang, i18n.BtnOpenPost), URL: link},
+	}
+
+	if source := postSource(link); source != "" && len(muteCallbackPrefix+source) <= maxCallbackData {
+		row = append(row, telegram.InlineKeyboardButton{
+			Text:         i18n.T(lang, i18n.BtnMuteSource),
+			CallbackData: muteCallbackPrefix + source,
+		})
+	}
+
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{row},
+	}
+}
+
+func channelKeyboard(lang i18n.Lang, link string) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: i18n.T(lang, i18n.BtnOpenPost), URL: link},
+		}},
+	}
+}
+
+// postSource returns the site a post was published on, e.g. "habr.com".
+func postSource(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+}
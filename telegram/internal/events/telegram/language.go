@@ -0,0 +1,75 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+)
+
+// language returns the language to answer a request in: the one chosen for
+// the chat with /lang, otherwise the language of the user's Telegram app.
+func (p *Processor) language(ctx context.Context, meta Meta) i18n.Lang {
+	if lang, ok := p.storedLanguage(ctx, meta.ChatID); ok {
+		return lang
+	}
+
+	lang, _ := i18n.Parse(meta.LanguageCode)
+
+	return lang
+}
+
+// chatLanguage returns the language to send posts to a chat in, when there
+// is no user to take the language from.
+func (p *Processor) chatLanguage(ctx context.Context, chatID int) i18n.Lang {
+	if lang, ok := p.storedLanguage(ctx, chatID); ok {
+		return lang
+	}
+
+	return i18n.Default
+}
+
+// storedLanguage returns the language chosen for the chat with /lang. Lookups
+// are cached, a failed one is logged and treated as no choice.
+func (p *Processor) storedLanguage(ctx context.Context, chatID int) (i18n.Lang, bool) {
+	p.mu.Lock()
+	lang, ok := p.languages[chatID]
+	p.mu.Unlock()
+
+	if !ok {
+		code, err := p.db.GetLanguage(ctx, chatID)
+		if err != nil {
+			log.Printf("can't get language of chat %d: %v", chatID, err)
+			return "", false
+		}
+
+		lang, _ = i18n.Supported(code)
+
+		p.mu.Lock()
+		p.languages[chatID] = lang
+		p.mu.Unlock()
+	}
+
+	return lang, lang != ""
+}
+
+func (p *Processor) setLanguage(ctx context.Context, req request) error {
+	if len(req.args) != 1 {
+		return p.reply(ctx, req.Meta, i18n.T(req.lang, i18n.LangUsage, req.lang))
+	}
+
+	lang, ok := i18n.Supported(req.args[0])
+	if !ok {
+		return p.reply(ctx, req.Meta, i18n.T(req.lang, i18n.LangUsage, req.lang))
+	}
+
+	if err := p.db.SetLanguage(ctx, req.ChatID, string(lang)); err != nil {
+		return fmt.Errorf("can't set language: %w", err)
+	}
+
+	p.mu.Lock()
+	p.languages[req.ChatID] = lang
+	p.mu.Unlock()
+
+	return p.reply(ctx, req.Meta, i18n.T(lang, i18n.LangChanged))
+}
@@ -0,0 +1,148 @@
+package telegram
+
+import (
+	"api/internal/clients/blogator"
+	"api/internal/clients/telegram"
+	"context"
+	"errors"
+	"fmt"
+	"i18n"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLatestCount = 10
+	maxLatestCount     = 50
+	defaultLatestDays  = 2
+	maxLatestDays      = 30
+
+	// latestPageSize is the number of posts in one message.
+	latestPageSize = 5
+
+	latestCallbackPrefix = "latest:"
+)
+
+var errBadLatestArgs = errors.New("bad /latest arguments")
+
+// latestQuery selects the newest count posts of the last days, starting
+// from the offset-th one.
+type latestQuery struct {
+	count  int
+	days   int
+	offset int
+}
+
+func (p *Processor) latest(ctx context.Context, req request) error {
+	q, err := parseLatestArgs(req.args)
+	if err != nil {
+		return p.reply(ctx, req.Meta, i18n.T(req.lang, i18n.LatestUsage))
+	}
+
+	return p.Post(ctx, req.Meta, req.lang, q)
+}
+
+// Post sends a page of the latest posts from blogator with a "More" button
+// leading to the next page, in reply to the message or button in meta.
+func (p *Processor) Post(ctx context.Context, meta Meta, lang i18n.Lang, q latestQuery) error {
+	since := time.Now().UTC().AddDate(0, 0, -q.days)
+	log.Printf("Fetching posts since %v", since)
+
+	items, err := p.ator.GetNewItems(ctx, since)
+	if err != nil {
+		return fmt.Errorf("can't get new posts: %w", err)
+	}
+
+	slices.SortFunc(items, func(a, b blogator.DataItem) int {
+		return b.CollectedDate.Compare(a.CollectedDate)
+	})
+	items = items[:min(len(items), q.count)]
+
+	if q.offset >= len(items) {
+		return p.reply(ctx, meta, i18n.T(lang, i18n.NoPosts))
+	}
+
+	log.Printf("Got %d new posts", len(items))
+
+	end := min(q.offset+latestPageSize, len(items))
+
+	var b strings.Builder
+	b.WriteString(i18n.N(lang, i18n.LatestHeader, q.days, q.offset+1, end, len(items), q.days))
+	for i, item := range items[q.offset:end] {
+		fmt.Fprintf(&b, "\n\n%d. <a href=\"%s\">%s</a>",
+			q.offset+i+1, telegram.EscapeHTML(item.Link), telegram.EscapeHTML(item.Title))
+	}
+
+	opts := []telegram.SendOption{
+		telegram.WithParseMode(telegram.ParseModeHTML),
+		telegram.WithoutLinkPreview(),
+	}
+	if end < len(items) {
+		next := q
+		next.offset = end
+		opts = append(opts, telegram.WithReplyMarkup(moreKeyboard(lang, next)))
+	}
+
+	return p.reply(ctx, meta, b.String(), opts...)
+}
+
+func moreKeyboard(lang i18n.Lang, q latestQuery) telegram.InlineKeyboardMarkup {
+	return telegram.InlineKeyboardMarkup{
+		InlineKeyboard: [][]telegram.InlineKeyboardButton{{
+			{Text: i18n.T(lang, i18n.BtnMore), CallbackData: latestCallbackData(q)},
+		}},
+	}
+}
+
+func latestCallbackData(q latestQuery) string {
+	return fmt.Sprintf("%s%d:%d:%d", latestCallbackPrefix, q.count, q.days, q.offset)
+}
+
+func parseLatestCallback(data string) (latestQuery, error) {
+	parts := strings.Split(strings.TrimPrefix(data, latestCallbackPrefix), ":")
+	if len(parts) != 3 {
+		return latestQuery{}, errBadLatestArgs
+	}
+
+	q, err := parseLatestArgs(parts[:2])
+	if err != nil {
+		return latestQuery{}, err
+	}
+
+	q.offset, err = strconv.Atoi(parts[2])
+	if err != nil || q.offset < 0 {
+		return latestQuery{}, errBadLatestArgs
+	}
+
+	return q, nil
+}
+
+// parseLatestArgs parses the optional count and days arguments of /latest.
+func parseLatestArgs(args []string) (latestQuery, error) {
+	q := latestQuery{count: defaultLatestCount, days: defaultLatestDays}
+
+	if len(args) > 2 {
+		return latestQuery{}, errBadLatestArgs
+	}
+
+	if len(args) > 0 {
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 1 || count > maxLatestCount {
+			return latestQuery{}, errBadLatestArgs
+		}
+		q.count = count
+	}
+
+	if len(args) > 1 {
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days < 1 || days > maxLatestDays {
+			return latestQuery{}, errBadLatestArgs
+		}
+		q.days = days
+	}
+
+	return q, nil
+}
@@ -0,0 +1,47 @@
+package telegram
+
+import (
+	"api/internal/clients/telegram"
+	"api/internal/events"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+)
+
+// processMembership tracks my_chat_member updates: a chat that blocked or
+// kicked the bot is deactivated and gets posts again once the bot is back.
+func (p *Processor) processMembership(ctx context.Context, event events.Event) error {
+	meta, err := meta(event)
+	if err != nil {
+		return fmt.Errorf("can't process membership: %w", err)
+	}
+
+	log.Printf("bot membership in chat %d changed to '%s' by '%s'", meta.ChatID, meta.MemberStatus, meta.Username)
+
+	active := true
+	switch meta.MemberStatus {
+	case telegram.MemberLeft, telegram.MemberKicked:
+		active = false
+	}
+
+	if err := p.db.SetChatActive(ctx, meta.ChatID, active); err != nil {
+		return fmt.Errorf("can't process membership: %w", err)
+	}
+
+	return nil
+}
+
+// deactivateUnavailable deactivates the chat if err means that messages can
+// never be delivered to it.
+func (p *Processor) deactivateUnavailable(ctx context.Context, chatID int, err error) {
+	if !errors.Is(err, telegram.ErrChatUnavailable) {
+		return
+	}
+
+	log.Printf("chat %d is unavailable, deactivating: %v", chatID, err)
+
+	if err := p.db.SetChatActive(ctx, chatID, false); err != nil {
+		log.Printf("can't deactivate chat %d: %v", chatID, err)
+	}
+}
@@ -1,10 +1,13 @@
 package telegram
 
 import (
+	"api/internal/clients/db"
 	"api/internal/clients/rabbitmq"
+	"api/internal/clients/telegram"
 	"context"
 	"fmt"
 	"log"
+	"strconv"
 )
 
 const (
@@ -16,30 +19,158 @@ func (p *Processor) SendPostToSubscribers(ctx context.Context, post rabbitmq.Res
 	if err != nil {
 		return fmt.Errorf("failed to get subscribers: %w", err)
 	}
-	log.Println("TEST TEST TEST")
-	if len(chatIDs) == 0 {
+	if len(chatIDs) == 0 && len(p.channels) == 0 {
 		log.Println("No subscribers to send post to")
 		return nil
 	}
 	log.Println("Sending post to subscribers", post.Data)
+
+	threads := p.chatThreads(ctx)
 	for _, ps := range post.Data {
-		text := fmt.Sprintf(`
-🗣 %s
-
-📰 %s
-
-🔗 %s
-`, ps.Comment, ps.Title, ps.Link)
-		log.Println("chatIDs: ", chatIDs)
-		log.Println("post: ", text)
-		for _, chatID := range chatIDs {
-			log.Printf("Sending post to chat %d: %s", chatID, ps.Title)
-
-			if err := p.tg.SendMessage(ctx, chatID, text); err != nil {
-				log.Printf("Error sending message to chat %d: %v", chatID, err)
-				continue
-			}
+		// Without the deliveries the post would be sent again to the chats
+		// that have it, so the message goes back to the queue instead.
+		delivered, err := p.db.Deliveries(ctx, messangerType, ps.ID)
+		if err != nil {
+			return fmt.Errorf("can't get deliveries of post %d: %w", ps.ID, err)
+		}
+
+		var deliveries []db.Delivery
+		if len(delivered) > 0 {
+			deliveries = p.editDelivered(ctx, ps, delivered)
+		} else {
+			deliveries = p.sendToChats(ctx, ps, chatIDs, threads)
+			deliveries = append(deliveries, p.sendToChannels(ctx, ps)...)
+		}
+
+		if err := p.db.SaveDeliveries(ctx, deliveries); err != nil {
+			log.Printf("Error saving deliveries of post %d: %v", ps.ID, err)
 		}
 	}
 	return nil
 }
+
+func (p *Processor) sendToChats(ctx context.Context, ps rabbitmq.DataItem, chatIDs []int, threads map[int]int) []db.Delivery {
+	muted := p.mutedChats(ctx, ps.Link)
+	log.Println("chatIDs: ", chatIDs)
+	log.Println("post: ", ps.Title)
+
+	deliveries := make([]db.Delivery, 0, len(chatIDs))
+	for _, chatID := range chatIDs {
+		if muted[chatID] {
+			continue
+		}
+
+		log.Printf("Sending post to chat %d: %s", chatID, ps.Title)
+
+		lang := p.chatLanguage(ctx, chatID)
+
+		sent, err := p.sendPost(ctx, chatID, ps.ImageURL, postCard(lang, ps),
+			telegram.WithParseMode(telegram.ParseModeHTML),
+			telegram.WithReplyMarkup(postKeyboard(lang, ps.Link)),
+			telegram.InThread(threads[chatID]),
+		)
+		if err != nil {
+			log.Printf("Error sending message to chat %d: %v", chatID, err)
+			p.deactivateUnavailable(ctx, chatID, err)
+		}
+
+		deliveries = append(deliveries, delivery(ps, strconv.Itoa(chatID), db.TargetChat, sent, err))
+	}
+
+	return deliveries
+}
+
+// sentPost is the messages a post was delivered as.
+type sentPost struct {
+	messageIDs []int
+	// caption reports that the post is the caption of a photo.
+	caption bool
+}
+
+// sendPost sends a post with its cover image as the caption of a photo, or as
+// a text message when there is no image or it can't be sent. A photo that is
+// sent without the rest of its caption is not sent again as text: the
+// message comes back together with the error.
+func (p *Processor) sendPost(ctx context.Context, chatID int, imageURL string, text string, opts ...telegram.SendOption) (sentPost, error) {
+	if imageURL != "" {
+		messageIDs, err := p.tg.SendPhotoWithIDs(ctx, chatID, imageURL, text, opts...)
+		if err == nil || len(messageIDs) > 0 {
+			return sentPost{messageIDs: messageIDs, caption: true}, err
+		}
+
+		log.Printf("Error sending photo to chat %d, falling back to text: %v", chatID, err)
+	}
+
+	messageIDs, err := p.tg.SendMessageWithIDs(ctx, chatID, text, opts...)
+
+	return sentPost{messageIDs: messageIDs}, err
+}
+
+// messageID returns the id of the first message of the post, zero if none
+// was sent.
+func (s sentPost) messageID() int {
+	if len(s.messageIDs) == 0 {
+		return 0
+	}
+
+	return s.messageIDs[0]
+}
+
+// chatThreads returns the forum topics the chats are subscribed in. A failed
+// lookup is logged and the posts go to the chats themselves.
+func (p *Processor) chatThreads(ctx context.Context) map[int]int {
+	threads, err := p.db.Threads(ctx, messangerType)
+	if err != nil {
+		log.Printf("can't get chat threads: %v", err)
+		return nil
+	}
+
+	return threads
+}
+
+// mutedChats returns the chats that muted the source of the post. A failed
+// lookup is logged and the post goes to every subscriber.
+func (p *Processor) mutedChats(ctx context.Context, link string) map[int]bool {
+	source := postSource(link)
+	if source == "" {
+		return nil
+	}
+
+	chatIDs, err := p.db.MutedChats(ctx, source)
+	if err != nil {
+		log.Printf("can't get chats that muted %s: %v", source, err)
+		return nil
+	}
+
+	muted := make(map[int]bool, len(chatIDs))
+	for _, chatID := range chatIDs {
+		muted[chatID] = true
+	}
+
+	return muted
+}
+
+func delivery(ps rabbitmq.DataItem, chatID string, target string, sent sentPost, err error) db.Delivery {
+	d := db.Delivery{
+		PostID:      ps.ID,
+		Messenger:   messangerType,
+		ChatID:      chatID,
+		Target:      target,
+		Status:      db.DeliverySent,
+		MessageID:   sent.messageID(),
+		MessageIDs:  sent.messageIDs,
+		Caption:     sent.caption,
+		UpdatedDate: ps.UpdatedDate,
+	}
+
+	if err != nil {
+		d.Error = err.Error()
+		// A post delivered in part stays sent, so that it is edited and
+		// retracted like the others.
+		if len(sent.messageIDs) == 0 {
+			d.Status = db.DeliveryFailed
+		}
+	}
+
+	return d
+}
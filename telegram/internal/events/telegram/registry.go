@@ -0,0 +1,189 @@
+package telegram
+
+import (
+	"api/internal/clients/telegram"
+	"context"
+	"fmt"
+	"i18n"
+	"slices"
+	"strings"
+)
+
+// command describes a bot command. The registry is the single source for
+// routing in doCmd, the help text and the menus set with setMyCommands.
+type command struct {
+	name        string
+	description i18n.Key
+	// scopes are the command menus the command is listed in.
+	scopes []string
+	// groupAdmin restricts the command to chat administrators in groups.
+	groupAdmin bool
+	// admin restricts the command to the bot admins' chats, it is listed
+	// only in their menus.
+	admin  bool
+	handle func(p *Processor, ctx context.Context, req request) error
+}
+
+// menuLanguages maps the language codes the command menus are registered for
+// to the catalog languages. The empty code is the list shown to users of any
+// other language.
+var menuLanguages = []struct {
+	code string
+	lang i18n.Lang
+}{
+	{"", i18n.English},
+	{"ru", i18n.Russian},
+}
+
+var menuScopes = []string{
+	telegram.ScopeDefault,
+	telegram.ScopeAllPrivateChats,
+	telegram.ScopeAllGroupChats,
+}
+
+func defaultCommands() []command {
+	return []command{
+		{
+			name:        StartCmd,
+			description: i18n.CmdStart,
+			scopes:      []string{telegram.ScopeDefault, telegram.ScopeAllPrivateChats},
+			handle: func(p *Processor, ctx context.Context, req request) error {
+				return p.sendHello(ctx, req.Meta, req.lang)
+			},
+		},
+		{
+			name:        HelpCmd,
+			description: i18n.CmdHelp,
+			scopes:      menuScopes,
+			handle: func(p *Processor, ctx context.Context, req request) error {
+				return p.sendHelp(ctx, req.Meta, req.lang)
+			},
+		},
+		{
+			name:        LatestCmd,
+			description: i18n.CmdLatest,
+			scopes:      menuScopes,
+			handle:      (*Processor).latest,
+		},
+		{
+			name:        SubscribeCmd,
+			description: i18n.CmdSubscribe,
+			scopes:      menuScopes,
+			groupAdmin:  true,
+			handle: func(p *Processor, ctx context.Context, req request) error {
+				return p.subscribe(ctx, req.Meta, req.lang)
+			},
+		},
+		{
+			name:        UnsubscribeCmd,
+			description: i18n.CmdUnsubscribe,
+			scopes:      menuScopes,
+			groupAdmin:  true,
+			handle: func(p *Processor, ctx context.Context, req request) error {
+				return p.unsubscribe(ctx, req.Meta, req.lang)
+			},
+		},
+		{
+			name:        LangCmd,
+			description: i18n.CmdLang,
+			scopes:      menuScopes,
+			groupAdmin:  true,
+			handle:      (*Processor).setLanguage,
+		},
+		{
+			name:        StatsCmd,
+			description: i18n.CmdStats,
+			admin:       true,
+			handle:      (*Processor).stats,
+		},
+		{
+			name:        BroadcastCmd,
+			description: i18n.CmdBroadcast,
+			admin:       true,
+			handle:      (*Processor).broadcast,
+		},
+		{
+			name:        PreviewCmd,
+			description: i18n.CmdPreview,
+			admin:       true,
+			handle:      (*Processor).preview,
+		},
+	}
+}
+
+func (c command) describe(lang i18n.Lang) string {
+	return i18n.T(lang, c.description)
+}
+
+func (p *Processor) command(name string) (command, bool) {
+	for _, cmd := range p.commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+func (p *Processor) helpText(lang i18n.Lang, admin bool) string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T(lang, i18n.HelpIntro))
+	for _, cmd := range p.commands {
+		if cmd.admin && !admin {
+			continue
+		}
+		fmt.Fprintf(&b, "\n%s - %s", cmd.name, cmd.describe(lang))
+	}
+
+	return b.String()
+}
+
+// RegisterCommands publishes the command menus for every scope and language
+// with setMyCommands. Admins' chats get the private chat menu extended with
+// the admin commands.
+func (p *Processor) RegisterCommands(ctx context.Context) error {
+	for _, scope := range menuScopes {
+		for _, menu := range menuLanguages {
+			cmds := p.menu(menu.lang, func(cmd command) bool {
+				return slices.Contains(cmd.scopes, scope)
+			})
+
+			if err := p.tg.SetMyCommands(ctx, cmds, telegram.BotCommandScope{Type: scope}, menu.code); err != nil {
+				return fmt.Errorf("can't register commands for scope %s: %w", scope, err)
+			}
+		}
+	}
+
+	for _, chatID := range p.admins {
+		for _, menu := range menuLanguages {
+			cmds := p.menu(menu.lang, func(cmd command) bool {
+				return cmd.admin || slices.Contains(cmd.scopes, telegram.ScopeAllPrivateChats)
+			})
+
+			scope := telegram.BotCommandScope{Type: telegram.ScopeChat, ChatID: chatID}
+			if err := p.tg.SetMyCommands(ctx, cmds, scope, menu.code); err != nil {
+				return fmt.Errorf("can't register admin commands for chat %d: %w", chatID, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (p *Processor) menu(lang i18n.Lang, listed func(cmd command) bool) []telegram.BotCommand {
+	var cmds []telegram.BotCommand
+
+	for _, cmd := range p.commands {
+		if !listed(cmd) {
+			continue
+		}
+
+		cmds = append(cmds, telegram.BotCommand{
+			Command:     strings.TrimPrefix(cmd.name, "/"),
+			Description: cmd.describe(lang),
+		})
+	}
+
+	return cmds
+}
@@ -0,0 +1,56 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// RetractPost deletes the messages a pulled post was delivered as and marks
+// its deliveries retracted. Deliveries that can't be deleted are left as
+// they are.
+func (p *Processor) RetractPost(ctx context.Context, postID int64) error {
+	delivered, err := p.db.Deliveries(ctx, messangerType, postID)
+	if err != nil {
+		return fmt.Errorf("can't get deliveries of post %d: %w", postID, err)
+	}
+
+	log.Printf("Retracting post %d from %d chats", postID, len(delivered))
+
+	retracted := make([]string, 0, len(delivered))
+	for _, d := range delivered {
+		chatID, err := strconv.Atoi(d.ChatID)
+		if err != nil {
+			log.Printf("Error parsing chat id %q of post %d: %v", d.ChatID, postID, err)
+			continue
+		}
+
+		if err := p.deleteMessages(ctx, chatID, d.MessageIDs); err != nil {
+			log.Printf("Error deleting messages %v in chat %d: %v", d.MessageIDs, chatID, err)
+			continue
+		}
+
+		retracted = append(retracted, d.ChatID)
+	}
+
+	if err := p.db.RetractDeliveries(ctx, messangerType, postID, retracted); err != nil {
+		return fmt.Errorf("can't retract deliveries of post %d: %w", postID, err)
+	}
+
+	return nil
+}
+
+// deleteMessages deletes every message of a delivery. The messages after one
+// that can't be deleted are still tried, so a retry has less left to delete.
+func (p *Processor) deleteMessages(ctx context.Context, chatID int, messageIDs []int) error {
+	var errs []error
+	for _, messageID := range messageIDs {
+		if err := p.tg.DeleteMessage(ctx, chatID, messageID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
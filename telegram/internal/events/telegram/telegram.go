@@ -3,40 +3,108 @@ package telegram
 import (
 	"api/internal/clients/blogator"
 	"api/internal/clients/db"
+	"api/internal/clients/rabbitmq"
 	"api/internal/clients/telegram"
 	"api/internal/events"
 	"context"
 	"errors"
 	"fmt"
+	"i18n"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Processor struct {
-	tg     *telegram.Client
-	ator   *blogator.Client
-	db     *db.Client
-	offset int
+	tg       *telegram.Client
+	ator     *blogator.Client
+	db       *db.Client
+	queue    *rabbitmq.Client
+	commands []command
+	channels []string
+	admins   []int
+	offset   int
+
+	mu         sync.Mutex
+	username   string
+	channelIDs map[string]int
+	languages  map[int]i18n.Lang
+	// broadcasts are the texts waiting for the admins' confirmation.
+	broadcasts map[int]string
+	// broadcasting is set while a confirmed broadcast is being sent.
+	broadcasting bool
+	// archive caches the recent posts searched by inline queries.
+	archive   []blogator.DataItem
+	archiveAt time.Time
+
+	// committed is the last offset persisted in db-service; offsetLoaded
+	// reports whether it has been restored after startup.
+	committed    int
+	offsetLoaded bool
 }
 
 type Meta struct {
 	ChatID   int
+	ChatType string
+	UserID   int
 	Username string
+	// LanguageCode is the language of the user's Telegram app.
+	LanguageCode string
+	// SenderChatID is set when a message is sent on behalf of a chat, e.g.
+	// by an anonymous group administrator.
+	SenderChatID int
+	// MigrateToChatID is set when a group has been upgraded to a supergroup.
+	MigrateToChatID int
+	// CallbackID is the id of the callback query for Callback events.
+	CallbackID string
+	// MemberStatus is the new status of the bot for Membership events.
+	MemberStatus string
+	// ThreadID is the forum topic the message was sent in.
+	ThreadID int
+	// IsForum reports a supergroup with topics enabled.
+	IsForum bool
+	// QueryID and Offset identify the page requested by InlineQuery events.
+	QueryID string
+	Offset  string
 }
 
+const offsetStateKey = "telegram.offset"
+
 var (
 	ErrUnknownEventType = errors.New("unknown event type")
 	ErrUnknownMetaType  = errors.New("unknown meta type")
 )
 
-func New(client *telegram.Client, blog *blogator.Client, db *db.Client) *Processor {
+func New(client *telegram.Client, blog *blogator.Client, db *db.Client, queue *rabbitmq.Client, channels []string, admins []int) *Processor {
 	return &Processor{
-		tg:   client,
-		ator: blog,
-		db:   db,
+		tg:         client,
+		ator:       blog,
+		db:         db,
+		queue:      queue,
+		commands:   defaultCommands(),
+		channels:   channels,
+		admins:     admins,
+		channelIDs: make(map[string]int),
+		languages:  make(map[int]i18n.Lang),
+		broadcasts: make(map[int]string),
 	}
 }
 
 func (p *Processor) Fetch(ctx context.Context, limit int) ([]events.Event, error) {
-	updates, err := p.tg.Updates(ctx, p.offset, limit)
+	if !p.offsetLoaded {
+		if err := p.loadOffset(ctx); err != nil {
+			return nil, fmt.Errorf("can`t load offset: %w", err)
+		}
+	}
+
+	// Telegram forgets the updates below the requested offset, so updates
+	// are requested from the committed offset and those still in work since
+	// an earlier fetch are skipped. A crash then loses nothing that is not
+	// handled yet, at the cost of a window: no more than limit updates past
+	// the oldest one in work are received until it is handled.
+	updates, err := p.tg.Updates(ctx, p.committed, limit)
 	if err != nil {
 		return nil, fmt.Errorf("can`t get updates: %w", err)
 	}
@@ -48,18 +116,67 @@ func (p *Processor) Fetch(ctx context.Context, limit int) ([]events.Event, error
 	res := make([]events.Event, 0, len(updates))
 
 	for _, u := range updates {
+		if u.ID < p.offset {
+			continue
+		}
 		res = append(res, event(u))
 	}
 
-	p.offset = updates[len(updates)-1].ID + 1
+	p.offset = max(p.offset, updates[len(updates)-1].ID+1)
 
 	return res, nil
 }
 
+// Commit persists the offset after the update with id. It must be called
+// only after that update and every update before it have been handled:
+// updates fetched but not committed are received again after a restart.
+func (p *Processor) Commit(ctx context.Context, id int) error {
+	offset := id + 1
+	if offset <= p.committed {
+		return nil
+	}
+
+	if err := p.db.SetState(ctx, offsetStateKey, strconv.Itoa(offset)); err != nil {
+		return fmt.Errorf("can`t commit offset: %w", err)
+	}
+
+	p.committed = offset
+
+	return nil
+}
+
+func (p *Processor) loadOffset(ctx context.Context) error {
+	value, err := p.db.GetState(ctx, offsetStateKey)
+	if err != nil {
+		return err
+	}
+
+	if value != "" {
+		offset, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("invalid stored offset %q: %w", value, err)
+		}
+
+		p.offset = offset
+		p.committed = offset
+		log.Printf("resuming updates from offset %d", offset)
+	}
+
+	p.offsetLoaded = true
+
+	return nil
+}
+
 func (p *Processor) Process(ctx context.Context, event events.Event) error {
 	switch event.Type {
 	case events.Message:
 		return p.processMessage(ctx, event)
+	case events.Callback:
+		return p.processCallback(ctx, event)
+	case events.Membership:
+		return p.processMembership(ctx, event)
+	case events.InlineQuery:
+		return p.processInlineQuery(ctx, event)
 	default:
 		return fmt.Errorf("can`t process event")
 	}
@@ -71,13 +188,33 @@ func (p *Processor) processMessage(ctx context.Context, event events.Event) erro
 		return fmt.Errorf("can't process message: %w", err)
 	}
 
-	if err := p.doCmd(ctx, event.Text, meta.ChatID, meta.Username); err != nil {
+	if meta.MigrateToChatID != 0 {
+		return p.migrateChat(ctx, meta.ChatID, meta.MigrateToChatID)
+	}
+
+	// Groups get every message when privacy mode is off, only commands are
+	// answered there.
+	if event.Text == "" || isGroup(meta.ChatType) && !strings.HasPrefix(event.Text, "/") {
+		return nil
+	}
+
+	if err := p.doCmd(ctx, event.Text, meta); err != nil {
 		return fmt.Errorf("can't process message: %w", err)
 	}
 
 	return nil
 }
 
+func (p *Processor) migrateChat(ctx context.Context, oldChatID int, newChatID int) error {
+	log.Printf("chat %d migrated to %d", oldChatID, newChatID)
+
+	if err := p.db.MigrateChat(ctx, oldChatID, newChatID); err != nil {
+		return fmt.Errorf("can't migrate chat %d: %w", oldChatID, err)
+	}
+
+	return nil
+}
+
 func meta(event events.Event) (Meta, error) {
 	res, ok := event.Meta.(Meta)
 	if !ok {
@@ -91,32 +228,114 @@ func event(upd telegram.Update) events.Event {
 	updType := fetchType(upd)
 
 	res := events.Event{
+		ID:   upd.ID,
 		Type: updType,
 		Text: fetchText(upd),
 	}
 
-	if updType == events.Message {
+	switch updType {
+	case events.Message:
+		msg := upd.Message
+
+		res.ChatID = msg.Chat.ID
+		m := Meta{
+			ChatID:          msg.Chat.ID,
+			ChatType:        msg.Chat.Type,
+			UserID:          msg.From.ID,
+			Username:        msg.From.Username,
+			LanguageCode:    msg.From.LanguageCode,
+			MigrateToChatID: msg.MigrateToChatID,
+			ThreadID:        threadID(msg),
+			IsForum:         msg.Chat.IsForum,
+		}
+		if msg.SenderChat != nil {
+			m.SenderChatID = msg.SenderChat.ID
+		}
+		res.Meta = m
+	case events.Callback:
+		// Messages sent via inline mode carry no chat, answer in private.
+		chat := telegram.Chat{ID: upd.CallbackQuery.From.ID, Type: telegram.ChatPrivate}
+		if upd.CallbackQuery.Message != nil {
+			chat = upd.CallbackQuery.Message.Chat
+		}
+
+		res.ChatID = chat.ID
 		res.Meta = Meta{
-			ChatID:   upd.Message.Chat.ID,
-			Username: upd.Message.From.Username,
+			ChatID:       chat.ID,
+			ChatType:     chat.Type,
+			ThreadID:     threadID(upd.CallbackQuery.Message),
+			IsForum:      chat.IsForum,
+			UserID:       upd.CallbackQuery.From.ID,
+			Username:     upd.CallbackQuery.From.Username,
+			LanguageCode: upd.CallbackQuery.From.LanguageCode,
+			CallbackID:   upd.CallbackQuery.ID,
+		}
+	case events.Membership:
+		member := upd.MyChatMember
+
+		res.ChatID = member.Chat.ID
+		res.Meta = Meta{
+			ChatID:       member.Chat.ID,
+			ChatType:     member.Chat.Type,
+			UserID:       member.From.ID,
+			Username:     member.From.Username,
+			LanguageCode: member.From.LanguageCode,
+			MemberStatus: member.NewChatMember.Status,
+		}
+	case events.InlineQuery:
+		query := upd.InlineQuery
+
+		// Inline queries come from no chat, the user's private chat keeps
+		// their language.
+		res.ChatID = query.From.ID
+		res.Meta = Meta{
+			ChatID:       query.From.ID,
+			ChatType:     telegram.ChatPrivate,
+			UserID:       query.From.ID,
+			Username:     query.From.Username,
+			LanguageCode: query.From.LanguageCode,
+			QueryID:      query.ID,
+			Offset:       query.Offset,
 		}
 	}
 
 	return res
 }
 
+// threadID returns the forum topic of the message, zero for messages outside
+// topics.
+func threadID(msg *telegram.IncomingMessage) int {
+	if msg == nil || !msg.IsTopicMessage {
+		return 0
+	}
+
+	return msg.MessageThreadID
+}
+
 func fetchText(upd telegram.Update) string {
-	if upd.Message == nil {
+	switch {
+	case upd.Message != nil:
+		return upd.Message.Text
+	case upd.CallbackQuery != nil:
+		return upd.CallbackQuery.Data
+	case upd.InlineQuery != nil:
+		return upd.InlineQuery.Query
+	default:
 		return ""
 	}
-
-	return upd.Message.Text
 }
 
 func fetchType(upd telegram.Update) events.Type {
-	if upd.Message == nil {
+	switch {
+	case upd.Message != nil:
+		return events.Message
+	case upd.CallbackQuery != nil:
+		return events.Callback
+	case upd.MyChatMember != nil:
+		return events.Membership
+	case upd.InlineQuery != nil:
+		return events.InlineQuery
+	default:
 		return events.Unknown
 	}
-
-	return events.Message
 }
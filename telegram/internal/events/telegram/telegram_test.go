@@ -0,0 +1,314 @@
+package telegram
+
+import (
+	"api/internal/clients/db"
+	"api/internal/clients/telegram"
+	event_consumer "api/internal/consumer/event-consumer"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeStateStore is the state API of db-service.
+type fakeStateStore struct {
+	mu    sync.Mutex
+	state map[string]string
+}
+
+func (s *fakeStateStore) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := strings.TrimPrefix(r.URL.Path, "/api/state/")
+
+	switch r.Method {
+	case http.MethodGet:
+		json.NewEncoder(w).Encode(map[string]any{"success": true, "data": s.state[key]})
+	case http.MethodPut:
+		var body struct {
+			Value string `json:"value"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		s.state[key] = body.Value
+		json.NewEncoder(w).Encode(map[string]any{"success": true})
+	}
+}
+
+// fakeBotAPI serves getUpdates: like Telegram it forgets the updates below
+// the requested offset. Every update is /help from a private chat, chat 1
+// unless chats says otherwise. sendMessage to blocked waits for release.
+type fakeBotAPI struct {
+	mu      sync.Mutex
+	updates []int
+	chats   map[int]int
+
+	blocked int
+	release chan struct{}
+	sent    chan int
+}
+
+func (a *fakeBotAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/sendMessage") {
+		a.sendMessage(w, r)
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var params struct {
+		Offset string `json:"offset"`
+		Limit  string `json:"limit"`
+	}
+	json.NewDecoder(r.Body).Decode(&params)
+	offset, _ := strconv.Atoi(params.Offset)
+	limit, _ := strconv.Atoi(params.Limit)
+
+	var kept []int
+	for _, id := range a.updates {
+		if id >= offset {
+			kept = append(kept, id)
+		}
+	}
+	a.updates = kept
+
+	result := []string{}
+	for _, id := range kept[:min(limit, len(kept))] {
+		chat := 1
+		if c, ok := a.chats[id]; ok {
+			chat = c
+		}
+		result = append(result, fmt.Sprintf(
+			`{"update_id":%d,"message":{"message_id":%d,"chat":{"id":%d,"type":"private"},"from":{"id":%d},"text":"/help"}}`,
+			id, id, chat, chat,
+		))
+	}
+
+	fmt.Fprintf(w, `{"ok":true,"result":[%s]}`, strings.Join(result, ","))
+}
+
+func (a *fakeBotAPI) sendMessage(w http.ResponseWriter, r *http.Request) {
+	var params map[string]any
+	json.NewDecoder(r.Body).Decode(&params)
+	chat, _ := strconv.Atoi(fmt.Sprint(params["chat_id"]))
+
+	if chat == a.blocked && a.release != nil {
+		select {
+		case <-a.release:
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if a.sent != nil {
+		a.sent <- chat
+	}
+
+	fmt.Fprint(w, `{"ok":true,"result":{"message_id":1}}`)
+}
+
+type offsetTest struct {
+	store  *fakeStateStore
+	dbAddr string
+	apiURL url.URL
+}
+
+func newOffsetTest(t *testing.T, updates ...int) *offsetTest {
+	t.Helper()
+
+	return newOffsetTestWithAPI(t, &fakeBotAPI{updates: updates})
+}
+
+func newOffsetTestWithAPI(t *testing.T, api *fakeBotAPI) *offsetTest {
+	t.Helper()
+
+	store := &fakeStateStore{state: make(map[string]string)}
+	dbServer := httptest.NewServer(store)
+	t.Cleanup(dbServer.Close)
+
+	apiServer := httptest.NewServer(api)
+	t.Cleanup(apiServer.Close)
+
+	apiURL, err := url.Parse(apiServer.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &offsetTest{
+		store:  store,
+		dbAddr: strings.TrimPrefix(dbServer.URL, "http://"),
+		apiURL: *apiURL,
+	}
+}
+
+// start returns a processor as after a restart of the bot.
+func (o *offsetTest) start() *Processor {
+	return New(telegram.New(o.apiURL, "token"), nil, db.New(o.dbAddr, "/api"), nil, nil, nil)
+}
+
+func fetchIDs(t *testing.T, p *Processor, limit int) []int {
+	t.Helper()
+
+	got, err := p.Fetch(context.Background(), limit)
+	if err != nil {
+		t.Fatalf("Fetch: %v", err)
+	}
+
+	ids := make([]int, 0, len(got))
+	for _, e := range got {
+		ids = append(ids, e.ID)
+	}
+
+	return ids
+}
+
+func TestOffsetSurvivesCrashBetweenFetchAndProcess(t *testing.T) {
+	o := newOffsetTest(t, 1, 2, 3)
+
+	// The bot crashes after fetching, before handling anything.
+	if got := fetchIDs(t, o.start(), 10); !equalIDs(got, []int{1, 2, 3}) {
+		t.Fatalf("first run fetched %v", got)
+	}
+
+	// Nothing was committed, so nothing is skipped.
+	p := o.start()
+	if got := fetchIDs(t, p, 10); !equalIDs(got, []int{1, 2, 3}) {
+		t.Fatalf("after crash fetched %v, want [1 2 3]", got)
+	}
+
+	// Update 1 is handled, the bot crashes while handling 2.
+	if err := p.Commit(context.Background(), 1); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := o.store.state[offsetStateKey]; got != "2" {
+		t.Fatalf("stored offset %q, want 2", got)
+	}
+
+	// Update 1 isn't replayed, 2 and 3 aren't skipped.
+	p = o.start()
+	if got := fetchIDs(t, p, 10); !equalIDs(got, []int{2, 3}) {
+		t.Fatalf("after second crash fetched %v, want [2 3]", got)
+	}
+
+	if err := p.Commit(context.Background(), 3); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if got := fetchIDs(t, o.start(), 10); len(got) != 0 {
+		t.Fatalf("after handling everything fetched %v", got)
+	}
+}
+
+func TestFetchKeepsUpdatesInWork(t *testing.T) {
+	o := newOffsetTest(t, 1, 2, 3)
+
+	p := o.start()
+	if got := fetchIDs(t, p, 2); !equalIDs(got, []int{1, 2}) {
+		t.Fatalf("fetched %v, want [1 2]", got)
+	}
+
+	// The next batch is fetched while the first one is still in work, and
+	// nothing is committed by fetching alone.
+	if got := fetchIDs(t, p, 10); !equalIDs(got, []int{3}) {
+		t.Fatalf("fetched %v, want [3]", got)
+	}
+	if got := o.store.state[offsetStateKey]; got != "" {
+		t.Fatalf("stored offset %q before any commit", got)
+	}
+
+	// Fetching further didn't make Telegram drop the updates in work.
+	if got := fetchIDs(t, o.start(), 10); !equalIDs(got, []int{1, 2, 3}) {
+		t.Fatalf("after crash fetched %v, want [1 2 3]", got)
+	}
+
+	// An older position is never committed over a newer one.
+	if err := p.Commit(context.Background(), 2); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := p.Commit(context.Background(), 1); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if got := o.store.state[offsetStateKey]; got != "3" {
+		t.Fatalf("stored offset %q, want 3", got)
+	}
+}
+
+func equalIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestBlockedChatDoesNotStallOtherChats(t *testing.T) {
+	// Chat 2 is blocked on update 1, other chats send the rest.
+	api := &fakeBotAPI{
+		updates: []int{1, 2, 3, 4, 5},
+		chats:   map[int]int{1: 2, 2: 3, 3: 5, 4: 7, 5: 9},
+		blocked: 2,
+		release: make(chan struct{}),
+		sent:    make(chan int, 10),
+	}
+	o := newOffsetTestWithAPI(t, api)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	p := o.start()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = event_consumer.New(p, p, 10, 2).Start(ctx)
+	}()
+
+	for range 4 {
+		select {
+		case chat := <-api.sent:
+			if chat == api.blocked {
+				t.Fatalf("answered chat %d while it is blocked", chat)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("other chats were stalled by the blocked chat")
+		}
+	}
+
+	if got := o.state(offsetStateKey); got != "" {
+		t.Fatalf("stored offset %q while update 1 is in work", got)
+	}
+
+	close(api.release)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for o.state(offsetStateKey) != "6" {
+		if time.Now().After(deadline) {
+			t.Fatalf("stored offset %q, want 6", o.state(offsetStateKey))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	cancel()
+	<-done
+}
+
+func (o *offsetTest) state(key string) string {
+	o.store.mu.Lock()
+	defer o.store.mu.Unlock()
+
+	return o.store.state[key]
+}
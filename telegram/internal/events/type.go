@@ -6,6 +6,14 @@ type Fetcher interface {
 	Fetch(ctx context.Context, limit int) ([]Event, error)
 }
 
+// Committer is implemented by fetchers that persist their position and need
+// to know which events have been handled.
+type Committer interface {
+	// Commit persists the position after the event with id. Every event
+	// fetched before it has been handled too.
+	Commit(ctx context.Context, id int) error
+}
+
 type Processor interface {
 	Process(ctx context.Context, e Event) error
 }
@@ -15,10 +23,20 @@ type Type int
 const (
 	Unknown Type = iota
 	Message
+	Callback
+	// Membership is a change of the bot's membership in a chat.
+	Membership
+	// InlineQuery is a query typed after the bot's username in any chat.
+	InlineQuery
 )
 
 type Event struct {
+	// ID is the id of the event at the fetcher, e.g. the update id.
+	ID   int
 	Type Type
 	Text string
-	Meta interface{}
+	// ChatID keeps the events of one chat in order when they are handled
+	// concurrently.
+	ChatID int
+	Meta   interface{}
 }
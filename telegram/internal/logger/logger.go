@@ -0,0 +1,41 @@
+// Package logger configures the structured logger of the service. Every
+// record, including the ones written with the standard log package, goes
+// through a handler that redacts tokens and other secrets.
+package logger
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// Setup makes a redacting text logger writing to stderr the default slog
+// logger and the output of the standard log package. secrets are redacted
+// verbatim in addition to the well-known token patterns. The standard log
+// package has no levels, so its output is logged at level itself and is
+// never dropped, errors included.
+func Setup(level string, secrets ...string) *slog.Logger {
+	lvl := ParseLevel(level)
+
+	h := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: lvl})
+	l := slog.New(NewRedactHandler(h, secrets...))
+
+	slog.SetDefault(l)
+	slog.SetLogLoggerLevel(lvl)
+
+	return l
+}
+
+// ParseLevel converts LOG_LEVEL to a slog level, info by default.
+func ParseLevel(level string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
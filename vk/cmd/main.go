@@ -12,8 +12,11 @@ import (
 	"vk/internal/clients/rabbitmq"
 	vkClient "vk/internal/clients/vk"
 	"vk/internal/config"
+	"vk/internal/consumer"
+	callback_consumer "vk/internal/consumer/callback-consumer"
 	event_consumer "vk/internal/consumer/event-consumer"
 	"vk/internal/events/vk"
+	"vk/internal/logger"
 )
 
 func main() {
@@ -22,11 +25,7 @@ func main() {
 
 	cfg := config.MustLoad()
 
-	eventProccessor := vk.New(
-		vkClient.New(cfg.VkToken),
-		blogator.New(cfg.AtorToken),
-		db.New(cfg.DbHost, cfg.DbPort),
-	)
+	logger.Setup(cfg.LogLevel, cfg.Secrets()...)
 
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
@@ -37,7 +36,20 @@ func main() {
 	}
 	defer rmq.Close()
 
+	vkAPI := vkClient.New(cfg.VkToken, cfg.VkGroupID, vkClient.WithAPIURL(cfg.VkAPIURL))
+
+	eventProccessor := vk.New(
+		vkAPI,
+		blogator.New(cfg.AtorToken),
+		db.New(cfg.DbHost, cfg.DbPort),
+		rmq,
+		cfg.Admins,
+	)
+
 	err = rmq.Consume(ctx, func(post rabbitmq.Response) error {
+		if post.Type == rabbitmq.PostDeleted {
+			return eventProccessor.RetractPost(ctx, post.PostID)
+		}
 		return eventProccessor.SendPostToSubscribers(ctx, post)
 	})
 
@@ -47,11 +59,33 @@ func main() {
 
 	log.Print("service started")
 
-	consumer := event_consumer.New(eventProccessor, eventProccessor)
+	var eventConsumer consumer.Consumer
+	var callback *callback_consumer.Consumer
+	switch cfg.VkMode {
+	case config.ModeCallback:
+		callback = callback_consumer.New(
+			eventProccessor,
+			eventProccessor,
+			cfg.VkCallbackAddr,
+			cfg.VkCallbackPath,
+			cfg.VkConfirmation,
+			cfg.VkSecret,
+		)
+		eventConsumer = callback
+	default:
+		eventConsumer = event_consumer.New(eventProccessor, eventProccessor)
+	}
 
 	go func() {
-		if err := consumer.Start(); err != nil {
-			log.Printf("Telegram consumer stopped: %v", err)
+		if cfg.VkMode == config.ModeLongPoll {
+			if err := vkAPI.Connect(ctx); err != nil {
+				log.Printf("VK long poll connection stopped: %v", err)
+				return
+			}
+		}
+
+		if err := eventConsumer.Start(ctx); err != nil {
+			log.Printf("VK consumer stopped: %v", err)
 			cancel()
 		}
 	}()
@@ -67,6 +101,13 @@ func main() {
 	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer shutdownCancel()
 
+	if callback != nil {
+		log.Println("Stopping Callback API server...")
+		if err := callback.Stop(shutdownCtx); err != nil {
+			log.Printf("Error stopping Callback API server: %v", err)
+		}
+	}
+
 	log.Println("Closing RabbitMQ connection...")
 	if err := rmq.Close(); err != nil {
 		log.Printf("Error closing RabbitMQ: %v", err)
@@ -5,4 +5,7 @@ go 1.22.5
 require (
 	github.com/joho/godotenv v1.5.1
 	github.com/rabbitmq/amqp091-go v1.10.0
+	i18n v0.0.0
 )
+
+replace i18n => ../i18n
@@ -6,7 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"net/url"
 	"path"
@@ -25,6 +25,11 @@ const (
 	methodDeleteChat = "deleteChat"
 	methodChatExist  = "chatExist"
 	methodAllChats   = "allChats"
+	methodChatActive = "chatActive"
+	methodStats      = "stats"
+	methodDeliveries = "deliveries"
+	methodRetract    = "retractDeliveries"
+	methodLanguage   = "language"
 )
 
 func New(h string, b string) *Client {
@@ -145,7 +150,7 @@ func (c *Client) ChatExists(ctx context.Context, chatId int) (bool, error) {
 	if err := json.Unmarshal(body, &success); err != nil {
 		return false, fmt.Errorf("can`t unmurshall json: %v", err)
 	}
-	log.Println(success)
+	slog.Debug("db-service response", "method", methodChatExist, "data", success.Data)
 	if !success.Success {
 		return false, fmt.Errorf("API returned non-success response")
 	}
@@ -158,7 +163,7 @@ func (c *Client) AllUsers(ctx context.Context, messangerType string) ([]int, err
 		Host:   c.Host,
 		Path:   path.Join(c.BasePath, methodAllChats, messangerType),
 	}
-	log.Println("making request to: ", u.String())
+	slog.Debug("db-service request", "url", u.String())
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("can`t make req: %v", err)
@@ -185,3 +190,290 @@ func (c *Client) AllUsers(ctx context.Context, messangerType string) ([]int, err
 	}
 	return res.Data, nil
 }
+
+// GetLanguage returns the language chosen for the chat with /lang or an empty
+// string if there is none.
+func (c *Client) GetLanguage(ctx context.Context, chatId int) (string, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodLanguage, messangerType, strconv.Itoa(chatId)),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res LanguageResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return "", fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return "", fmt.Errorf("error while getting language of chat %d", chatId)
+	}
+	return res.Data, nil
+}
+
+func (c *Client) SetLanguage(ctx context.Context, chatId int, lang string) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodLanguage, messangerType, strconv.Itoa(chatId)),
+	}
+
+	jsonData, err := json.Marshal(map[string]string{"lang": lang})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while saving language of chat %d", chatId)
+	}
+	return nil
+}
+
+// SetChatActive marks a subscribed chat as reachable or not. Inactive chats
+// are skipped by AllUsers.
+func (c *Client) SetChatActive(ctx context.Context, chatId int, active bool) error {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodChatActive, strconv.Itoa(chatId)),
+	}
+
+	jsonData, err := json.Marshal(map[string]bool{"active": active})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while updating chat %d", chatId)
+	}
+	return nil
+}
+
+func (c *Client) SaveDeliveries(ctx context.Context, deliveries []Delivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodDeliveries),
+	}
+
+	jsonData, err := json.Marshal(deliveries)
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while saving deliveries")
+	}
+	return nil
+}
+
+// RetractDeliveries marks the deliveries of the post to the chats as
+// retracted.
+func (c *Client) RetractDeliveries(ctx context.Context, messangerType string, postID int64, chatIDs []string) error {
+	if len(chatIDs) == 0 {
+		return nil
+	}
+
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodRetract),
+	}
+
+	jsonData, err := json.Marshal(RetractRequest{
+		PostID:    postID,
+		Messenger: messangerType,
+		ChatIDs:   chatIDs,
+	})
+	if err != nil {
+		return fmt.Errorf("can't marshal request data: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return fmt.Errorf("can't make req: %v", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var success ErrorResponse
+	if err := json.Unmarshal(body, &success); err != nil {
+		return fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !success.Success {
+		return fmt.Errorf("error while retracting deliveries of post %d", postID)
+	}
+	return nil
+}
+
+// Deliveries returns the latest delivery of the post to every chat where
+// the message id is known.
+func (c *Client) Deliveries(ctx context.Context, messangerType string, postID int64) ([]Delivery, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodDeliveries, messangerType, strconv.FormatInt(postID, 10)),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res DeliveriesResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("error while getting deliveries of post %d", postID)
+	}
+	return res.Data, nil
+}
+
+// Stats returns the number of active and inactive chats of every messenger.
+func (c *Client) Stats(ctx context.Context) ([]ChatStats, error) {
+	u := url.URL{
+		Scheme: "http",
+		Host:   c.Host,
+		Path:   path.Join(c.BasePath, methodStats),
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can`t make req: %v", err)
+	}
+
+	resp, err := c.DB.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can`t do request: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	var res StatsResponse
+	if err := json.Unmarshal(body, &res); err != nil {
+		return nil, fmt.Errorf("can`t unmurshall json: %v", err)
+	}
+
+	if !res.Success {
+		return nil, fmt.Errorf("error while getting stats")
+	}
+	return res.Data, nil
+}
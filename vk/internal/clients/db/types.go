@@ -12,6 +12,51 @@ type Response struct {
 	Data    []int `json:"data"`
 }
 
+type LanguageResponse struct {
+	Success bool   `json:"success"`
+	Data    string `json:"data"`
+}
+
+// Delivery targets.
+const (
+	TargetChat = "chat"
+)
+
+// Delivery statuses.
+const (
+	DeliverySent   = "sent"
+	DeliveryFailed = "failed"
+	DeliveryEdited = "edited"
+)
+
+type RetractRequest struct {
+	PostID    int64    `json:"post_id"`
+	Messenger string   `json:"messenger"`
+	ChatIDs   []string `json:"chat_ids"`
+}
+
+type Delivery struct {
+	PostID    int64  `json:"post_id"`
+	Messenger string `json:"messenger"`
+	ChatID    string `json:"chat_id"`
+	Target    string `json:"target"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	// MessageID is the id of the message the post was delivered as, the
+	// conversation message id in a group chat.
+	MessageID int `json:"message_id,omitempty"`
+	// MessageIDs are the ids of every message the post was delivered as
+	// when it is split into several, starting with MessageID.
+	MessageIDs []int `json:"message_ids,omitempty"`
+	// UpdatedDate is the update date of the delivered version of the post.
+	UpdatedDate time.Time `json:"updated_date"`
+}
+
+type DeliveriesResponse struct {
+	Success bool       `json:"success"`
+	Data    []Delivery `json:"data"`
+}
+
 type DataItem struct {
 	ID            int64     `json:"id"`
 	Title         string    `json:"title"`
@@ -19,3 +64,14 @@ type DataItem struct {
 	UpdatedDate   time.Time `json:"updatedDate"`
 	CollectedDate time.Time `json:"collectedDate"`
 }
+
+type ChatStats struct {
+	Messenger string `json:"messenger"`
+	Active    int    `json:"active"`
+	Inactive  int    `json:"inactive"`
+}
+
+type StatsResponse struct {
+	Success bool        `json:"success"`
+	Data    []ChatStats `json:"data"`
+}
@@ -91,6 +91,9 @@ func (c *Client) Consume(ctx context.Context, handler func(post Response) error)
 					d.Nack(false, false) // Reject message
 					continue
 				}
+				if post.Type == "" {
+					post.Type = d.Type
+				}
 
 				if err := handler(post); err != nil {
 					log.Printf("Error handling message: %v", err)
@@ -106,6 +109,34 @@ func (c *Client) Consume(ctx context.Context, handler func(post Response) error)
 	return nil
 }
 
+// Peek returns the next message of the queue without consuming it. ok is
+// false if the queue is empty, which is the usual case while a consumer is
+// running.
+func (c *Client) Peek() (post Response, ok bool, err error) {
+	ch, err := c.conn.Channel()
+	if err != nil {
+		return Response{}, false, fmt.Errorf("can`t create channel: %w", err)
+	}
+	defer ch.Close()
+
+	d, ok, err := ch.Get(c.queue, false)
+	if err != nil {
+		return Response{}, false, fmt.Errorf("can`t get message: %w", err)
+	}
+	if !ok {
+		return Response{}, false, nil
+	}
+
+	// Put the message back at the head of the queue.
+	defer d.Nack(false, true)
+
+	if err := json.Unmarshal(d.Body, &post); err != nil {
+		return Response{}, false, fmt.Errorf("can`t unmarshal message: %w", err)
+	}
+
+	return post, true, nil
+}
+
 // Stop останавливает потребителя и ждет завершения всех горутин
 func (c *Client) Stop() {
 	close(c.stopChan)
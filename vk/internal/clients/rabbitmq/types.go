@@ -2,9 +2,19 @@ package rabbitmq
 
 import "time"
 
+// Message types. Messages with no type carry new posts.
+const (
+	PostDeleted = "post.deleted"
+)
+
 type Response struct {
+	// Type is the message type, taken from the AMQP type property when the
+	// body has none.
+	Type    string     `json:"type,omitempty"`
 	Success bool       `json:"success"`
 	Data    []DataItem `json:"data"`
+	// PostID is the post a post.deleted message retracts.
+	PostID int64 `json:"post_id,omitempty"`
 }
 
 type DataItem struct {
@@ -12,6 +22,8 @@ type DataItem struct {
 	Comment       string    `json:"comment"`
 	Title         string    `json:"title"`
 	Link          string    `json:"link"`
+	Summary       string    `json:"summary,omitempty"`
+	ImageURL      string    `json:"image_url,omitempty"`
 	UpdatedDate   time.Time `json:"updatedDate"`
 	CollectedDate time.Time `json:"collectedDate"`
 }
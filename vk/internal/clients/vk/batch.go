@@ -0,0 +1,142 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// maxBatchPeers is how many peers messages.send accepts in peer_ids.
+const maxBatchPeers = 100
+
+// SendResult is the outcome of a message sent to one peer of a batch.
+type SendResult struct {
+	PeerID int
+	// MessageIDs are the ids of the messages sent to the peer, one per
+	// part. Group chats have conversation message ids, see messageID.
+	MessageIDs []int
+	// Parts is how many parts of the message the peer got. A peer with an
+	// error and some parts has the beginning of the message.
+	Parts int
+	Err   error
+}
+
+// Partial reports whether the peer got only the beginning of the message.
+func (r SendResult) Partial() bool {
+	return r.Err != nil && r.Parts > 0
+}
+
+// peerResult is an item of the messages.send response for peer_ids.
+type peerResult struct {
+	PeerID                int `json:"peer_id"`
+	MessageID             int `json:"message_id"`
+	ConversationMessageID int `json:"conversation_message_id"`
+	Error                 *struct {
+		Code        int    `json:"code"`
+		Description string `json:"description"`
+	} `json:"error,omitempty"`
+}
+
+// messageID returns the id the message is edited and deleted by. A
+// community gets no message ids in group chats, only conversation message
+// ids.
+func (p peerResult) messageID() int {
+	if isChat(p.PeerID) {
+		return p.ConversationMessageID
+	}
+
+	return p.MessageID
+}
+
+// SendBatch sends message with the attachment to every peer with one request
+// per 100 peers instead of one per peer. The results follow the order of
+// peerIDs, a peer VK couldn't deliver to has its own error. key identifies
+// the logical send as in SendMessageWithID.
+func (c *Client) SendBatch(ctx context.Context, key string, peerIDs []int, message string, attachment string) []SendResult {
+	results := make([]SendResult, 0, len(peerIDs))
+
+	for start := 0; start < len(peerIDs); start += maxBatchPeers {
+		batch := peerIDs[start:min(start+maxBatchPeers, len(peerIDs))]
+		results = append(results, c.sendBatch(ctx, key, batch, message, attachment)...)
+	}
+
+	return results
+}
+
+// sendBatch sends message to at most maxBatchPeers peers. A message longer
+// than VK allows is sent as several messages in order; a peer that fails a
+// part doesn't get the rest and keeps the parts it has got.
+func (c *Client) sendBatch(ctx context.Context, key string, peerIDs []int, message string, attachment string) []SendResult {
+	parts := splitText(message, maxMessageLength)
+	if len(parts) == 0 {
+		parts = []string{message}
+	}
+
+	results := make([]SendResult, len(peerIDs))
+	index := make(map[int]int, len(peerIDs))
+	for i, peerID := range peerIDs {
+		results[i].PeerID = peerID
+		index[peerID] = i
+	}
+
+	for i, part := range parts {
+		var pending []string
+		for _, r := range results {
+			if r.Err == nil {
+				pending = append(pending, strconv.Itoa(r.PeerID))
+			}
+		}
+		if len(pending) == 0 {
+			break
+		}
+
+		q := url.Values{}
+		q.Add("peer_ids", strings.Join(pending, ","))
+		q.Add("message", part)
+		q.Add("random_id", strconv.FormatInt(int64(batchRandomID(key, peerIDs, i)), 10))
+		if attachment != "" && i == 0 {
+			q.Add("attachment", attachment)
+		}
+
+		resp, err := c.call(ctx, "messages.send", q)
+		if err == nil {
+			var peers []peerResult
+			if err = json.Unmarshal(resp, &peers); err != nil {
+				err = fmt.Errorf("can't unmarshal send results: %w", err)
+			} else {
+				for _, p := range peers {
+					j, ok := index[p.PeerID]
+					if !ok {
+						continue
+					}
+
+					if p.Error != nil {
+						results[j].Err = &APIError{Code: p.Error.Code, Message: p.Error.Description}
+						continue
+					}
+
+					results[j].MessageIDs = append(results[j].MessageIDs, p.messageID())
+				}
+			}
+		}
+
+		if err != nil && len(parts) > 1 {
+			err = fmt.Errorf("part %d of %d: %w", i+1, len(parts), err)
+		}
+
+		for j := range results {
+			switch {
+			case results[j].Err != nil:
+			case err != nil:
+				results[j].Err = err
+			default:
+				results[j].Parts = i + 1
+			}
+		}
+	}
+
+	return results
+}
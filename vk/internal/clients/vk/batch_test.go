@@ -0,0 +1,65 @@
+package vk
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSendBatchMessageIDs(t *testing.T) {
+	const chat = ChatPeerOffset + 1
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/method/messages.send", func(w http.ResponseWriter, r *http.Request) {
+		var peers []peerResult
+		for _, id := range strings.Split(r.FormValue("peer_ids"), ",") {
+			peerID, _ := strconv.Atoi(id)
+
+			// VK has no message ids for a community in group chats.
+			p := peerResult{PeerID: peerID, MessageID: 100 + peerID, ConversationMessageID: 7}
+			if peerID == chat {
+				p.MessageID = 0
+			}
+			peers = append(peers, p)
+		}
+
+		writeResponse(t, w, peers)
+	})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	c := New("token", 1, WithAPIURL(srv.URL+"/method"), WithHTTPClient(srv.Client()))
+
+	results := c.SendBatch(context.Background(), "post:1:100", []int{1, chat}, "text", "")
+
+	want := map[int][]int{1: {101}, chat: {7}}
+	for _, r := range results {
+		if r.Err != nil {
+			t.Fatalf("SendBatch() error for peer %d = %v", r.PeerID, r.Err)
+		}
+		if !reflect.DeepEqual(r.MessageIDs, want[r.PeerID]) {
+			t.Errorf("SendBatch() message ids for peer %d = %v, want %v", r.PeerID, r.MessageIDs, want[r.PeerID])
+		}
+	}
+}
+
+func TestMessageIDParam(t *testing.T) {
+	tests := []struct {
+		peerID int
+		want   string
+	}{
+		{peerID: 42, want: "message_ids"},
+		{peerID: ChatPeerOffset + 1, want: "conversation_message_ids"},
+	}
+
+	for _, tt := range tests {
+		if got := messageIDParam(tt.peerID, "message_ids"); got != tt.want {
+			t.Errorf("messageIDParam(%d) = %q, want %q", tt.peerID, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package vk
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrChatUnavailable matches errors after which messages can never be
+// delivered to the peer.
+var ErrChatUnavailable = errors.New("chat is unavailable")
+
+// codeTooManyRequests means the rate limit is exceeded.
+const codeTooManyRequests = 6
+
+// maxAttempts is how many times a request is made while the rate limit is
+// exceeded.
+const maxAttempts = 3
+
+// VK error codes of permanently unreachable peers.
+const (
+	codePermissionDenied = 7
+	codeBlacklisted      = 900
+	codeMessagesDenied   = 901
+)
+
+// APIError is an error returned by the VK API.
+type APIError struct {
+	Code    int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("VK API error: %d - %s", e.Code, e.Message)
+}
+
+// Is makes errors.Is(err, ErrChatUnavailable) report permanent errors.
+func (e *APIError) Is(target error) bool {
+	if target != ErrChatUnavailable {
+		return false
+	}
+
+	switch e.Code {
+	case codePermissionDenied, codeBlacklisted, codeMessagesDenied:
+		return true
+	default:
+		return false
+	}
+}
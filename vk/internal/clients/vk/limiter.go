@@ -0,0 +1,56 @@
+package vk
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// VK allows a community token 20 requests per second.
+const requestsPerSecond = 20
+
+// limiter spaces the API requests evenly so that the rate limit is kept.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newLimiter(rate int) *limiter {
+	return &limiter{interval: time.Second / time.Duration(rate)}
+}
+
+// wait blocks until a request may be made.
+func (l *limiter) wait(ctx context.Context) error {
+	delay := l.reserve()
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// reserve takes the next free slot and returns how long the caller must wait
+// for it.
+func (l *limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+
+	return delay
+}
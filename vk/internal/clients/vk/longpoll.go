@@ -0,0 +1,336 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// longPollWait is how long in seconds the server holds a request without
+// updates.
+const longPollWait = 25
+
+// Failure codes of the Bots Long Poll API.
+const (
+	// failedHistory means some events are lost, polling goes on from the new
+	// ts.
+	failedHistory = 1
+	// failedKeyExpired means a new key has to be requested.
+	failedKeyExpired = 2
+	// failedInfoLost means both a new key and a new ts have to be requested.
+	failedInfoLost = 3
+)
+
+// Delays between the attempts of Connect.
+const (
+	connectMinDelay = time.Second
+	connectMaxDelay = time.Minute
+)
+
+// legacyMessageVersion is the last API version whose message_new object is
+// the message itself rather than {message, client_info}.
+const legacyMessageVersion = "5.102"
+
+// Connect requests the Bots Long Poll server, retrying with exponential
+// backoff until it succeeds or ctx is cancelled.
+func (c *Client) Connect(ctx context.Context) error {
+	delay := connectMinDelay
+	for {
+		err := c.connect(ctx)
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("can't connect to VK long poll, retrying in %s: %v", delay, err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+
+		delay = min(delay*2, connectMaxDelay)
+	}
+}
+
+// connect resolves the community and requests a long poll server for it.
+func (c *Client) connect(ctx context.Context) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	return c.connectLocked(ctx)
+}
+
+// connectLocked is connect for a caller holding connMu.
+func (c *Client) connectLocked(ctx context.Context) error {
+	if c.groupID == 0 {
+		groupID, err := c.resolveGroupID(ctx)
+		if err != nil {
+			return err
+		}
+		c.groupID = groupID
+	}
+
+	c.negotiateVersion(ctx)
+
+	server, err := c.getLongPollServer(ctx)
+	if err != nil {
+		return err
+	}
+	c.longPoll = server
+
+	return nil
+}
+
+// Updates waits for the community events. Failures the Bots Long Poll API
+// can recover from are handled here and reported as no updates: an expired
+// key is requested again. A client that hasn't connected yet connects first.
+func (c *Client) Updates(ctx context.Context) ([]LongPollUpdate, error) {
+	server, err := c.server(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	q := url.Values{}
+	q.Add("act", "a_check")
+	q.Add("key", server.Key)
+	q.Add("ts", server.TS.String())
+	q.Add("wait", strconv.Itoa(longPollWait))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.Server+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("can't read response: %w", err)
+	}
+
+	var longPollResp LongPollResponse
+	if err := json.Unmarshal(body, &longPollResp); err != nil {
+		return nil, fmt.Errorf("can't unmarshal response: %w", err)
+	}
+
+	switch longPollResp.Failed {
+	case 0:
+		c.setTS(longPollResp.TS)
+		return longPollResp.Updates, nil
+	case failedHistory:
+		log.Printf("long poll history is lost, continuing from ts %s", longPollResp.TS)
+		c.setTS(longPollResp.TS)
+		return nil, nil
+	case failedKeyExpired:
+		return nil, c.refreshLongPoll(ctx, true)
+	case failedInfoLost:
+		return nil, c.refreshLongPoll(ctx, false)
+	default:
+		if err := c.refreshLongPoll(ctx, false); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("long poll error code: %d", longPollResp.Failed)
+	}
+}
+
+// server returns the long poll server to read the updates from, connecting
+// first if the client hasn't connected yet.
+func (c *Client) server(ctx context.Context) (longPollServer, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	if c.longPoll.Server == "" {
+		if err := c.connectLocked(ctx); err != nil {
+			return longPollServer{}, err
+		}
+	}
+
+	return c.longPoll, nil
+}
+
+func (c *Client) setTS(ts json.Number) {
+	c.connMu.Lock()
+	c.longPoll.TS = ts
+	c.connMu.Unlock()
+}
+
+// refreshLongPoll requests a new key of the long poll server. The current ts
+// is kept when keepTS is set so that no events are skipped.
+func (c *Client) refreshLongPoll(ctx context.Context, keepTS bool) error {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+
+	server, err := c.getLongPollServer(ctx)
+	if err != nil {
+		return fmt.Errorf("can't refresh long poll server: %w", err)
+	}
+
+	if keepTS {
+		server.TS = c.longPoll.TS
+	}
+	c.longPoll = server
+
+	return nil
+}
+
+// getLongPollServer requests a long poll server. The caller holds connMu.
+func (c *Client) getLongPollServer(ctx context.Context) (longPollServer, error) {
+	q := url.Values{}
+	q.Add("group_id", strconv.Itoa(c.groupID))
+
+	data, err := c.call(ctx, "groups.getLongPollServer", q)
+	if err != nil {
+		return longPollServer{}, fmt.Errorf("can't get long poll server: %w", err)
+	}
+
+	var server longPollServer
+	if err := json.Unmarshal(data, &server); err != nil {
+		return longPollServer{}, fmt.Errorf("can't unmarshal long poll server: %w", err)
+	}
+
+	return server, nil
+}
+
+// negotiateVersion asks VK to format the events for the client's API
+// version and to send the events the bot handles. Tokens without the manage
+// scope can't change the settings; the events then come in the version
+// configured for the community and are parsed for it. The caller holds
+// connMu.
+func (c *Client) negotiateVersion(ctx context.Context) {
+	q := url.Values{}
+	q.Add("group_id", strconv.Itoa(c.groupID))
+	q.Add("enabled", "1")
+	q.Add("api_version", c.apiVersion)
+	q.Add(EventMessageNew, "1")
+	q.Add(EventMessageEvent, "1")
+
+	if _, err := c.call(ctx, "groups.setLongPollSettings", q); err != nil {
+		log.Printf("can't set long poll API version %s: %v", c.apiVersion, err)
+	}
+}
+
+// resolveGroupID returns the id of the community the token belongs to.
+func (c *Client) resolveGroupID(ctx context.Context) (int, error) {
+	data, err := c.call(ctx, "groups.getById", url.Values{})
+	if err != nil {
+		return 0, fmt.Errorf("can't get community: %w", err)
+	}
+
+	type group struct {
+		ID int `json:"id"`
+	}
+
+	// Since 5.192 the groups are wrapped in an object.
+	var groups []group
+	if err := json.Unmarshal(data, &groups); err != nil {
+		var wrapped struct {
+			Groups []group `json:"groups"`
+		}
+		if err := json.Unmarshal(data, &wrapped); err != nil {
+			return 0, fmt.Errorf("can't unmarshal community: %w", err)
+		}
+		groups = wrapped.Groups
+	}
+
+	if len(groups) == 0 {
+		return 0, fmt.Errorf("token doesn't belong to a community")
+	}
+
+	return groups[0].ID, nil
+}
+
+// MessageNew returns the message of a message_new update. Before API version
+// 5.103 the object is the message itself, later it wraps the message
+// together with the client info.
+func (u LongPollUpdate) MessageNew() (Message, error) {
+	if u.Type != EventMessageNew {
+		return Message{}, fmt.Errorf("not a %s update: %s", EventMessageNew, u.Type)
+	}
+
+	if u.V != "" {
+		// An unknown version is told by the shape of the object below.
+		cmp, err := compareVersions(u.V, legacyMessageVersion)
+		if err == nil && cmp <= 0 {
+			return unmarshalMessage(u.Object)
+		}
+	}
+
+	var obj struct {
+		Message *Message `json:"message"`
+	}
+	if err := json.Unmarshal(u.Object, &obj); err != nil {
+		return Message{}, fmt.Errorf("can't unmarshal %s: %w", EventMessageNew, err)
+	}
+	if obj.Message == nil {
+		// Events without v come in the version configured for the community.
+		return unmarshalMessage(u.Object)
+	}
+
+	return *obj.Message, nil
+}
+
+// MessageEvent returns the button press of a message_event update.
+func (u LongPollUpdate) MessageEvent() (MessageEvent, error) {
+	if u.Type != EventMessageEvent {
+		return MessageEvent{}, fmt.Errorf("not a %s update: %s", EventMessageEvent, u.Type)
+	}
+
+	var event MessageEvent
+	if err := json.Unmarshal(u.Object, &event); err != nil {
+		return MessageEvent{}, fmt.Errorf("can't unmarshal %s: %w", EventMessageEvent, err)
+	}
+
+	return event, nil
+}
+
+func unmarshalMessage(data json.RawMessage) (Message, error) {
+	var msg Message
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return Message{}, fmt.Errorf("can't unmarshal %s: %w", EventMessageNew, err)
+	}
+
+	return msg, nil
+}
+
+// compareVersions compares API versions such as "5.103" part by part.
+func compareVersions(a, b string) (int, error) {
+	var aMajor, aMinor, bMajor, bMinor int
+	if _, err := fmt.Sscanf(a, "%d.%d", &aMajor, &aMinor); err != nil {
+		return 0, fmt.Errorf("invalid API version %q: %w", a, err)
+	}
+	if _, err := fmt.Sscanf(b, "%d.%d", &bMajor, &bMinor); err != nil {
+		return 0, fmt.Errorf("invalid API version %q: %w", b, err)
+	}
+
+	if aMajor != bMajor {
+		return aMajor - bMajor, nil
+	}
+
+	return aMinor - bMinor, nil
+}
+
+// AnswerMessageEvent answers a callback button press so that the client
+// stops waiting for it.
+func (c *Client) AnswerMessageEvent(ctx context.Context, event MessageEvent) error {
+	q := url.Values{}
+	q.Add("event_id", event.EventID)
+	q.Add("user_id", strconv.Itoa(event.UserID))
+	q.Add("peer_id", strconv.Itoa(event.PeerID))
+
+	if _, err := c.call(ctx, "messages.sendMessageEventAnswer", q); err != nil {
+		return fmt.Errorf("can't answer message event: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,189 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+)
+
+// fakeLongPoll is a VK API and a Bots Long Poll server. Every
+// groups.getLongPollServer hands out a new key and ts, a_check answers with
+// the queued responses and records the key and ts it was called with.
+type fakeLongPoll struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	servers   int
+	responses []LongPollResponse
+	checks    []longPollServer
+}
+
+func newFakeLongPoll(t *testing.T, responses ...LongPollResponse) *fakeLongPoll {
+	f := &fakeLongPoll{responses: responses}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/method/groups.getLongPollServer", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		f.servers++
+		server := longPollServer{
+			Server: f.srv.URL + "/poll",
+			Key:    fmt.Sprintf("key%d", f.servers),
+			TS:     json.Number(fmt.Sprint(f.servers * 10)),
+		}
+		f.mu.Unlock()
+
+		writeResponse(t, w, server)
+	})
+	mux.HandleFunc("/method/groups.setLongPollSettings", func(w http.ResponseWriter, r *http.Request) {
+		writeResponse(t, w, 1)
+	})
+	mux.HandleFunc("/poll", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+
+		f.checks = append(f.checks, longPollServer{
+			Key: r.URL.Query().Get("key"),
+			TS:  json.Number(r.URL.Query().Get("ts")),
+		})
+
+		resp := LongPollResponse{TS: json.Number(r.URL.Query().Get("ts"))}
+		if len(f.responses) > 0 {
+			resp, f.responses = f.responses[0], f.responses[1:]
+		}
+
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			t.Errorf("can't encode long poll response: %v", err)
+		}
+	})
+
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+
+	return f
+}
+
+func writeResponse(t *testing.T, w http.ResponseWriter, v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Errorf("can't marshal response: %v", err)
+		return
+	}
+
+	if err := json.NewEncoder(w).Encode(APIResponse{Response: data}); err != nil {
+		t.Errorf("can't encode response: %v", err)
+	}
+}
+
+func TestUpdatesRecoversFromFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		response LongPollResponse
+		// wantKey and wantTS are what the next a_check is called with.
+		wantKey     string
+		wantTS      string
+		wantServers int
+	}{
+		{
+			name:        "updates",
+			response:    LongPollResponse{TS: "11", Updates: []LongPollUpdate{{Type: EventMessageNew}}},
+			wantKey:     "key1",
+			wantTS:      "11",
+			wantServers: 1,
+		},
+		{
+			name:        "history lost",
+			response:    LongPollResponse{Failed: failedHistory, TS: "30"},
+			wantKey:     "key1",
+			wantTS:      "30",
+			wantServers: 1,
+		},
+		{
+			name:        "key expired",
+			response:    LongPollResponse{Failed: failedKeyExpired},
+			wantKey:     "key2",
+			wantTS:      "10",
+			wantServers: 2,
+		},
+		{
+			name:        "info lost",
+			response:    LongPollResponse{Failed: failedInfoLost},
+			wantKey:     "key2",
+			wantTS:      "20",
+			wantServers: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newFakeLongPoll(t, tt.response)
+			c := New("token", 1, WithAPIURL(f.srv.URL+"/method"), WithHTTPClient(f.srv.Client()))
+			ctx := context.Background()
+
+			updates, err := c.Updates(ctx)
+			if err != nil {
+				t.Fatalf("Updates() error = %v", err)
+			}
+			if len(updates) != len(tt.response.Updates) {
+				t.Errorf("Updates() returned %d updates, want %d", len(updates), len(tt.response.Updates))
+			}
+
+			if _, err := c.Updates(ctx); err != nil {
+				t.Fatalf("second Updates() error = %v", err)
+			}
+
+			f.mu.Lock()
+			defer f.mu.Unlock()
+
+			if first := f.checks[0]; first.Key != "key1" || first.TS != "10" {
+				t.Errorf("first a_check with key %s and ts %s, want key1 and 10", first.Key, first.TS)
+			}
+			if next := f.checks[1]; next.Key != tt.wantKey || next.TS != json.Number(tt.wantTS) {
+				t.Errorf("next a_check with key %s and ts %s, want %s and %s", next.Key, next.TS, tt.wantKey, tt.wantTS)
+			}
+			if f.servers != tt.wantServers {
+				t.Errorf("long poll server requested %d times, want %d", f.servers, tt.wantServers)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{a: "5.103", b: "5.102", want: 1},
+		{a: "5.102", b: "5.102", want: 0},
+		{a: "5.92", b: "5.102", want: -1},
+		{a: "4.200", b: "5.102", want: -1},
+		{a: "", b: "5.102", wantErr: true},
+		{a: "five", b: "5.102", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := compareVersions(tt.a, tt.b)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("compareVersions(%q, %q) error = %v, want error %v", tt.a, tt.b, err, tt.wantErr)
+			continue
+		}
+		if sign(got) != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want sign %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func sign(n int) int {
+	switch {
+	case n > 0:
+		return 1
+	case n < 0:
+		return -1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,229 @@
+package vk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+)
+
+const (
+	getMessagesUploadServerMethod = "photos.getMessagesUploadServer"
+	saveMessagesPhotoMethod       = "photos.saveMessagesPhoto"
+)
+
+const (
+	// maxPhotoSize is the largest image downloaded for upload, VK rejects
+	// bigger photos anyway.
+	maxPhotoSize = 50 << 20
+	// maxCachedPhotos bounds the cache of uploaded photos.
+	maxCachedPhotos = 100
+)
+
+// ChatPeerOffset is added to the id of a group chat to make its peer id.
+const ChatPeerOffset = 2000000000
+
+// UploadPeer returns the peer a photo sent to peerID has to be uploaded for.
+// Group chats need photos of their own, personal dialogs share the photos of
+// the community, reported as 0.
+func UploadPeer(peerID int) int {
+	if isChat(peerID) {
+		return peerID
+	}
+
+	return 0
+}
+
+// isChat reports whether the peer is a group chat.
+func isChat(peerID int) bool {
+	return peerID >= ChatPeerOffset
+}
+
+type UploadServer struct {
+	UploadURL string `json:"upload_url"`
+}
+
+type UploadedPhoto struct {
+	Server int    `json:"server"`
+	Photo  string `json:"photo"`
+	Hash   string `json:"hash"`
+}
+
+type SavedPhoto struct {
+	ID        int    `json:"id"`
+	OwnerID   int    `json:"owner_id"`
+	AccessKey string `json:"access_key"`
+}
+
+// SendPhoto sends message with the image at imageURL attached.
+func (c *Client) SendPhoto(ctx context.Context, peerID int, message string, imageURL string) error {
+	_, err := c.SendPhotoWithIDs(ctx, "", peerID, message, imageURL)
+	return err
+}
+
+// SendPhotoWithIDs is SendPhoto that returns the ids of the messages sent,
+// the photo is attached to the first one. If a part of a long message isn't
+// sent, the ids of the parts before it come with the error. key identifies
+// the logical send as in SendMessageWithID.
+func (c *Client) SendPhotoWithIDs(ctx context.Context, key string, peerID int, message string, imageURL string) ([]int, error) {
+	attachment, err := c.UploadPhoto(ctx, peerID, imageURL)
+	if err != nil {
+		return nil, fmt.Errorf("can't upload photo: %w", err)
+	}
+
+	return c.send(ctx, key, peerID, message, attachment, "")
+}
+
+// UploadPhoto uploads the image at imageURL for messages to the peer and
+// returns it as an attachment. Uploaded photos are cached per UploadPeer, so
+// a post broadcast to many peers is uploaded once.
+func (c *Client) UploadPhoto(ctx context.Context, peerID int, imageURL string) (string, error) {
+	uploadPeer := UploadPeer(peerID)
+	cacheKey := strconv.Itoa(uploadPeer) + ":" + imageURL
+
+	c.mu.Lock()
+	attachment, ok := c.photos[cacheKey]
+	c.mu.Unlock()
+
+	if ok {
+		return attachment, nil
+	}
+
+	image, err := c.download(ctx, imageURL)
+	if err != nil {
+		return "", fmt.Errorf("can't download image: %w", err)
+	}
+
+	q := url.Values{}
+	if uploadPeer != 0 {
+		q.Add("peer_id", strconv.Itoa(uploadPeer))
+	}
+
+	data, err := c.call(ctx, getMessagesUploadServerMethod, q)
+	if err != nil {
+		return "", err
+	}
+
+	var server UploadServer
+	if err := json.Unmarshal(data, &server); err != nil {
+		return "", fmt.Errorf("can't unmarshal upload server: %w", err)
+	}
+
+	uploaded, err := c.upload(ctx, server.UploadURL, imageURL, image)
+	if err != nil {
+		return "", err
+	}
+
+	q = url.Values{}
+	q.Add("server", strconv.Itoa(uploaded.Server))
+	q.Add("photo", uploaded.Photo)
+	q.Add("hash", uploaded.Hash)
+
+	data, err = c.call(ctx, saveMessagesPhotoMethod, q)
+	if err != nil {
+		return "", err
+	}
+
+	var saved []SavedPhoto
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return "", fmt.Errorf("can't unmarshal saved photo: %w", err)
+	}
+	if len(saved) == 0 {
+		return "", fmt.Errorf("no photo saved")
+	}
+
+	attachment = fmt.Sprintf("photo%d_%d", saved[0].OwnerID, saved[0].ID)
+	if saved[0].AccessKey != "" {
+		attachment += "_" + saved[0].AccessKey
+	}
+
+	c.mu.Lock()
+	if len(c.photos) >= maxCachedPhotos {
+		clear(c.photos)
+	}
+	c.photos[cacheKey] = attachment
+	c.mu.Unlock()
+
+	return attachment, nil
+}
+
+func (c *Client) download(ctx context.Context, imageURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imageURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("can't create request: %w", err)
+	}
+
+	resp, err := c.downloads.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	image, err := io.ReadAll(io.LimitReader(resp.Body, maxPhotoSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("can't read response: %w", err)
+	}
+	if len(image) > maxPhotoSize {
+		return nil, fmt.Errorf("image is larger than %d bytes", maxPhotoSize)
+	}
+
+	return image, nil
+}
+
+func (c *Client) upload(ctx context.Context, uploadURL string, imageURL string, image []byte) (UploadedPhoto, error) {
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+
+	name := "photo.jpg"
+	if u, err := url.Parse(imageURL); err == nil && path.Ext(u.Path) != "" {
+		name = path.Base(u.Path)
+	}
+
+	part, err := w.CreateFormFile("photo", name)
+	if err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't create form file: %w", err)
+	}
+	if _, err := part.Write(image); err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't write form file: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't close form: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, &body)
+	if err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't create request: %w", err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't read response: %w", err)
+	}
+
+	var uploaded UploadedPhoto
+	if err := json.Unmarshal(data, &uploaded); err != nil {
+		return UploadedPhoto{}, fmt.Errorf("can't unmarshal uploaded photo: %w", err)
+	}
+	if uploaded.Photo == "" || uploaded.Photo == "[]" {
+		return UploadedPhoto{}, fmt.Errorf("photo was not uploaded")
+	}
+
+	return uploaded, nil
+}
@@ -0,0 +1,51 @@
+package vk
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"slices"
+)
+
+// randomID returns the random_id of a part of a message sent to the peer.
+// VK drops a message whose random_id it has already seen for the peer, so
+// ids derived from the key make every retry of one logical send deliver the
+// message once, while different keys, peers and parts give different ids. A
+// message without a key gets a fresh random id.
+func randomID(key string, peerID int, part int) int32 {
+	var id int32
+	if key == "" {
+		id = rand.Int32()
+	} else {
+		h := fnv.New32a()
+		fmt.Fprintf(h, "%s\x00%d\x00%d", key, peerID, part)
+		id = int32(h.Sum32())
+	}
+
+	// Zero turns the check for duplicates off.
+	if id == 0 {
+		id = 1
+	}
+
+	return id
+}
+
+// batchRandomID is the random_id of a part of a message sent to several
+// peers with one request. It depends on the whole set of peers in any order,
+// so that a retry of the same chunk reuses it while chunks sharing a peer
+// don't.
+func batchRandomID(key string, peerIDs []int, part int) int32 {
+	if key == "" {
+		return randomID("", 0, part)
+	}
+
+	peers := slices.Clone(peerIDs)
+	slices.Sort(peers)
+
+	h := fnv.New64a()
+	for _, peerID := range peers {
+		fmt.Fprintf(h, "%d,", peerID)
+	}
+
+	return randomID(fmt.Sprintf("%s\x00%x", key, h.Sum64()), 0, part)
+}
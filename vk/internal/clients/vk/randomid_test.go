@@ -0,0 +1,69 @@
+package vk
+
+import "testing"
+
+func TestRandomID(t *testing.T) {
+	type args struct {
+		key    string
+		peerID int
+		part   int
+	}
+
+	base := args{key: "post:1:100", peerID: 42, part: 0}
+
+	tests := []struct {
+		name string
+		a, b args
+		same bool
+	}{
+		{name: "same input", a: base, b: base, same: true},
+		{name: "other key", a: base, b: args{key: "post:2:100", peerID: 42, part: 0}},
+		{name: "other peer", a: base, b: args{key: "post:1:100", peerID: 43, part: 0}},
+		{name: "other part", a: base, b: args{key: "post:1:100", peerID: 42, part: 1}},
+		{name: "other version", a: base, b: args{key: "post:1:101", peerID: 42, part: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := randomID(tt.a.key, tt.a.peerID, tt.a.part)
+			b := randomID(tt.b.key, tt.b.peerID, tt.b.part)
+
+			if a == 0 || b == 0 {
+				t.Fatalf("randomID() = 0, which turns the check for duplicates off")
+			}
+			if (a == b) != tt.same {
+				t.Errorf("randomID(%+v) = %d, randomID(%+v) = %d, want same %v", tt.a, a, tt.b, b, tt.same)
+			}
+		})
+	}
+}
+
+func TestRandomIDWithoutKey(t *testing.T) {
+	seen := make(map[int32]bool)
+	for range 10 {
+		id := randomID("", 42, 0)
+		if id == 0 {
+			t.Fatalf("randomID() = 0, which turns the check for duplicates off")
+		}
+		seen[id] = true
+	}
+
+	// Ten random int32 colliding down to one value means the id isn't random.
+	if len(seen) < 2 {
+		t.Errorf("randomID() without a key gave %d distinct ids of 10", len(seen))
+	}
+}
+
+func TestBatchRandomID(t *testing.T) {
+	const key = "post:1:100"
+
+	if batchRandomID(key, []int{3, 1, 2}, 0) != batchRandomID(key, []int{1, 2, 3}, 0) {
+		t.Errorf("batchRandomID() depends on the order of the peers")
+	}
+	if batchRandomID(key, []int{1, 2, 3}, 0) == batchRandomID(key, []int{1, 4, 5}, 0) {
+		t.Errorf("batchRandomID() is the same for chunks sharing only the first peer")
+	}
+	if batchRandomID(key, []int{1, 2, 3}, 0) == batchRandomID(key, []int{1, 2, 3}, 1) {
+		t.Errorf("batchRandomID() is the same for different parts")
+	}
+}
@@ -0,0 +1,115 @@
+package vk
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxMessageLength is the VK limit for message text. It is counted in UTF-16
+// code units here, which is never less than the number of characters.
+const maxMessageLength = 4096
+
+// Kinds of places text may be split at, from the weakest to the strongest.
+const (
+	breakWord = iota
+	breakSentence
+	breakLine
+	breakParagraph
+)
+
+type breakPoint struct {
+	pos   int
+	width int
+}
+
+// splitText splits text into parts of at most limit UTF-16 code units. Parts
+// end at paragraph, line, sentence or word boundaries when possible.
+func splitText(text string, limit int) []string {
+	var parts []string
+
+	for text != "" {
+		head, tail := cutText(text, limit)
+		if head != "" {
+			parts = append(parts, head)
+		}
+		text = tail
+	}
+
+	return parts
+}
+
+// cutText returns the first part of text that fits into limit and the rest.
+func cutText(text string, limit int) (head string, tail string) {
+	var (
+		breaks [breakParagraph + 1]breakPoint
+		width  int
+		cut    = -1
+	)
+
+	for i := 0; i < len(text); {
+		r, n := utf8.DecodeRuneInString(text[i:])
+		w := 1
+		if r >= 0x10000 {
+			w = 2
+		}
+
+		if width+w > limit && width > 0 {
+			cut = i
+			break
+		}
+
+		width += w
+		i += n
+
+		if kind, ok := breakAt(text, i); ok {
+			breaks[kind] = breakPoint{pos: i, width: width}
+		}
+	}
+
+	if cut < 0 {
+		return text, ""
+	}
+
+	// Prefer the strongest boundary that still leaves the part half full,
+	// then the last boundary of any kind, and cut mid-word as a last resort.
+	pos := 0
+	for kind := breakParagraph; kind >= breakWord; kind-- {
+		if b := breaks[kind]; b.pos > 0 && b.width >= limit/2 {
+			pos = b.pos
+			break
+		}
+	}
+	if pos == 0 {
+		for _, b := range breaks {
+			pos = max(pos, b.pos)
+		}
+	}
+	if pos == 0 {
+		pos = cut
+	}
+
+	return strings.TrimRightFunc(text[:pos], isSpace), strings.TrimLeftFunc(text[pos:], isSpace)
+}
+
+// breakAt reports whether text may be split at pos and the kind of the break.
+func breakAt(text string, pos int) (int, bool) {
+	prefix := text[:pos]
+
+	switch {
+	case strings.HasSuffix(prefix, "\n\n"):
+		return breakParagraph, true
+	case strings.HasSuffix(prefix, "\n"):
+		return breakLine, true
+	case strings.HasSuffix(prefix, ". "), strings.HasSuffix(prefix, "! "),
+		strings.HasSuffix(prefix, "? "), strings.HasSuffix(prefix, "… "):
+		return breakSentence, true
+	case strings.HasSuffix(prefix, " "):
+		return breakWord, true
+	default:
+		return 0, false
+	}
+}
+
+func isSpace(r rune) bool {
+	return r == ' ' || r == '\n' || r == '\t' || r == '\r'
+}
@@ -4,32 +4,89 @@ import (
 	"encoding/json"
 )
 
+// Types of the Bots Long Poll updates the bot handles.
+const (
+	EventMessageNew   = "message_new"
+	EventMessageEvent = "message_event"
+	// EventConfirmation is sent by the Callback API to confirm the server.
+	EventConfirmation = "confirmation"
+)
+
 type LongPollResponse struct {
-	TS      json.Number     `json:"ts"`
-	Updates json.RawMessage `json:"updates"`
-	Failed  int             `json:"failed,omitempty"`
+	TS      json.Number      `json:"ts"`
+	Updates []LongPollUpdate `json:"updates"`
+	Failed  int              `json:"failed,omitempty"`
 }
 
 type LongPollUpdate struct {
-	Type    string `json:"type"`
-	Object  Object `json:"object"`
-	GroupId int    `json:"group_id"`
+	Type    string          `json:"type"`
+	Object  json.RawMessage `json:"object"`
+	GroupID int             `json:"group_id"`
+	EventID string          `json:"event_id"`
+	// V is the API version the object is formatted for.
+	V string `json:"v,omitempty"`
+}
+
+// CallbackUpdate is an event sent to the Callback API server.
+type CallbackUpdate struct {
+	LongPollUpdate
+	// Secret is the secret key set in the community settings.
+	Secret string `json:"secret"`
 }
 
-type Object struct {
+// Message is a message received by the community.
+type Message struct {
+	ID     int    `json:"id"`
+	PeerID int    `json:"peer_id"`
+	FromID int    `json:"from_id"`
 	Text   string `json:"text"`
-	FromId int    `json:"from_id"`
+	// Payload is the JSON payload of the keyboard button that sent the
+	// message.
+	Payload string `json:"payload,omitempty"`
+}
+
+// MessageEvent is a press of a callback keyboard button.
+type MessageEvent struct {
+	UserID                int             `json:"user_id"`
+	PeerID                int             `json:"peer_id"`
+	EventID               string          `json:"event_id"`
+	Payload               json.RawMessage `json:"payload"`
+	ConversationMessageID int             `json:"conversation_message_id"`
+}
+
+// ButtonCallback is the type of a button whose press comes as message_event
+// instead of a message from the user.
+const ButtonCallback = "callback"
+
+// Keyboard is a bot keyboard sent with a message. An inline one is shown
+// under the message.
+type Keyboard struct {
+	Inline  bool       `json:"inline"`
+	Buttons [][]Button `json:"buttons"`
+}
+
+type Button struct {
+	Action ButtonAction `json:"action"`
+}
+
+type ButtonAction struct {
+	Type  string `json:"type"`
+	Label string `json:"label"`
+	// Payload is a JSON object of up to 255 characters sent back with the
+	// press.
+	Payload string `json:"payload,omitempty"`
 }
 
-type MessageResponse struct {
-	Response Connection     `json:"response"`
-	Error    *ErrorResponse `json:"error,omitempty"`
+type APIResponse struct {
+	Response json.RawMessage `json:"response"`
+	Error    *ErrorResponse  `json:"error,omitempty"`
 }
 
-type Connection struct {
-	TS     int    `json:"ts"`
-	Key    string `json:"key"`
-	Server string `json:"server"`
+// longPollServer is the response of groups.getLongPollServer.
+type longPollServer struct {
+	Server string      `json:"server"`
+	Key    string      `json:"key"`
+	TS     json.Number `json:"ts"`
 }
 
 type ErrorResponse struct {
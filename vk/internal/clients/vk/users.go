@@ -0,0 +1,47 @@
+package vk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+const getUsersMethod = "users.get"
+
+type User struct {
+	ID        int      `json:"id"`
+	FirstName string   `json:"first_name"`
+	LastName  string   `json:"last_name"`
+	Country   *Country `json:"country,omitempty"`
+}
+
+type Country struct {
+	ID    int    `json:"id"`
+	Title string `json:"title"`
+}
+
+// GetUser returns the profile of a user with the country filled in when the
+// user has shared it.
+func (c *Client) GetUser(ctx context.Context, userID int) (User, error) {
+	q := url.Values{}
+	q.Add("user_ids", strconv.Itoa(userID))
+	q.Add("fields", "country")
+
+	data, err := c.call(ctx, getUsersMethod, q)
+	if err != nil {
+		return User{}, err
+	}
+
+	var users []User
+	if err := json.Unmarshal(data, &users); err != nil {
+		return User{}, fmt.Errorf("can't unmarshal users: %w", err)
+	}
+
+	if len(users) == 0 {
+		return User{}, fmt.Errorf("user %d not found", userID)
+	}
+
+	return users[0], nil
+}
@@ -3,231 +3,332 @@ package vk
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 )
 
 type Client struct {
-	longPollUrl string
-	apiUrl      string
-	token       string
-	client      http.Client
-	apiVersion  string
-	key         string
-	server      string
+	apiUrl     string
+	token      string
+	client     *http.Client
+	apiVersion string
+	// downloads fetches the images to upload. It doesn't share the API
+	// client, which has no timeout to outlast the long poll wait.
+	downloads *http.Client
+
+	// connMu guards the community and the Bots Long Poll server the updates
+	// are read from: Connect sets them while the client already sends
+	// messages.
+	connMu   sync.Mutex
+	groupID  int
+	longPoll longPollServer
+
+	limiter *limiter
+
+	mu     sync.Mutex
+	photos map[string]string
 }
 
-const (
-	getLongPollServerUrl = "https://api.vk.com/method/messages.getLongPollServer"
-)
+// defaultAPIURL is the address the API methods are called at.
+const defaultAPIURL = "https://api.vk.com/method"
 
-func New(token string) *Client {
-	server, key, ts, err := GetLongPollServer(context.Background(), token, "5.199")
-	if err != nil {
-		log.Fatal("can`t get params: ", err)
-	}
-	longPoll := longPoll(server, key, ts)
-	return &Client{
-		longPollUrl: longPoll,
-		apiUrl:      "https://api.vk.com/method",
-		token:       token,
-		client:      http.Client{},
-		apiVersion:  "5.199",
-		key:         key,
-		server:      server,
+// downloadTimeout bounds the download of an image to upload.
+const downloadTimeout = 2 * time.Minute
+
+// Option configures a Client.
+type Option func(c *Client)
+
+// WithAPIURL makes the client call the API methods at apiURL instead of the
+// VK one, e.g. a proxy or a fake server.
+func WithAPIURL(apiURL string) Option {
+	return func(c *Client) {
+		c.apiUrl = strings.TrimSuffix(apiURL, "/")
 	}
 }
 
-func (c *Client) Updates(ctx context.Context) ([]LongPollUpdate, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.longPollUrl, nil)
-	if err != nil {
-		return nil, fmt.Errorf("can't create request: %w", err)
+// WithHTTPClient makes the client send requests with hc, e.g. one with a
+// proxy. A timeout of hc has to outlast the long poll wait.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.client = hc
 	}
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("can't execute request: %w", err)
-	}
-	defer resp.Body.Close()
+}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("can't read response: %w", err)
+// New creates a client of the community with groupID without any requests to
+// VK. A zero groupID is resolved from the token. The Bots Long Poll server is
+// requested by Connect or by the first Updates call; the Callback API doesn't
+// need it.
+func New(token string, groupID int, opts ...Option) *Client {
+	c := &Client{
+		apiUrl:     defaultAPIURL,
+		token:      token,
+		client:     &http.Client{},
+		apiVersion: "5.199",
+		downloads:  &http.Client{Timeout: downloadTimeout},
+		groupID:    groupID,
+		limiter:    newLimiter(requestsPerSecond),
+		photos:     make(map[string]string),
 	}
 
-	var longPollResp LongPollResponse
-	if err := json.Unmarshal(body, &longPollResp); err != nil {
-		return nil, fmt.Errorf("can't unmarshal response: %w", err)
+	for _, opt := range opts {
+		opt(c)
 	}
 
-	if longPollResp.Failed != 0 {
-		if longPollResp.Failed == 4 {
-			return nil, fmt.Errorf("long poll error code: %d", longPollResp.Failed)
-		}
+	return c
+}
 
-		ts, err := longPollResp.TS.Int64()
-		if err != nil {
-			return nil, fmt.Errorf("invalid TS format: %w", err)
-		}
-		c.setlongPoll(longPoll(c.server, c.key, int(ts)))
-		return nil, fmt.Errorf("long poll error code: %d", longPollResp.Failed)
-	}
+func (c *Client) SendMessage(ctx context.Context, peerID int, message string) error {
+	_, err := c.send(ctx, "", peerID, message, "", "")
+	return err
+}
 
-	ts, err := longPollResp.TS.Int64()
+// SendMessageWithKeyboard sends message with the keyboard under it. A message
+// longer than VK allows gets the keyboard under its last part.
+func (c *Client) SendMessageWithKeyboard(ctx context.Context, peerID int, message string, keyboard Keyboard) error {
+	data, err := json.Marshal(keyboard)
 	if err != nil {
-		return nil, fmt.Errorf("invalid TS format: %w", err)
+		return fmt.Errorf("can't marshal keyboard: %w", err)
 	}
-	c.setlongPoll(longPoll(c.server, c.key, int(ts)))
 
-	return c.parseUpdates(&longPollResp)
+	_, err = c.send(ctx, "", peerID, message, "", string(data))
+	return err
 }
 
-func (c *Client) parseUpdates(resp *LongPollResponse) ([]LongPollUpdate, error) {
-	if resp == nil || len(resp.Updates) == 0 {
-		return nil, nil
+// SendMessageWithID is SendMessage that returns the id of the first message
+// sent. key identifies the logical send: sending again with the same key
+// doesn't deliver the message twice.
+func (c *Client) SendMessageWithID(ctx context.Context, key string, peerID int, message string) (int, error) {
+	messageIDs, err := c.send(ctx, key, peerID, message, "", "")
+	if err != nil {
+		return 0, err
 	}
 
-	var arrayUpdates [][]interface{}
-	if err := json.Unmarshal(resp.Updates, &arrayUpdates); err != nil {
-		return nil, fmt.Errorf("can't parse updates: %w", err)
+	return messageIDs[0], nil
+}
+
+// SendMessageWithIDs is SendMessageWithID that returns the ids of every
+// message sent. If a part isn't sent, the ids of the parts before it come
+// with the error.
+func (c *Client) SendMessageWithIDs(ctx context.Context, key string, peerID int, message string) ([]int, error) {
+	return c.send(ctx, key, peerID, message, "", "")
+}
+
+// EditMessage replaces the text and the attachment of the messages a message
+// was sent as. The new message is split the same way and every part is
+// edited into the message at its place, the attachment goes with the first
+// one; parts beyond the messages are sent after them and messages beyond the
+// parts are deleted. It returns the ids of the messages that hold the
+// message now, including the ones left as they were by an error.
+func (c *Client) EditMessage(ctx context.Context, peerID int, messageIDs []int, message string, attachment string) ([]int, error) {
+	parts := splitText(message, maxMessageLength)
+	if len(parts) == 0 {
+		parts = []string{message}
 	}
 
-	updates := make([]LongPollUpdate, 0, len(arrayUpdates))
+	edited := make([]int, 0, len(parts))
+	for i, part := range parts {
+		if i >= len(messageIDs) {
+			messageID, err := c.sendPart(ctx, "", peerID, i, part, "", "")
+			if err != nil {
+				return edited, fmt.Errorf("can't send message: %w", err)
+			}
 
-	for _, arr := range arrayUpdates {
-		if len(arr) < 6 {
+			edited = append(edited, messageID)
 			continue
 		}
 
-		eventID, ok := arr[0].(float64)
-		if !ok || int(eventID) != 4 {
-			continue
+		q := url.Values{}
+		q.Add("peer_id", strconv.Itoa(peerID))
+		q.Add(messageIDParam(peerID, "message_id"), strconv.Itoa(messageIDs[i]))
+		q.Add("message", part)
+		if attachment != "" && i == 0 {
+			q.Add("attachment", attachment)
 		}
 
-		flags, ok := arr[2].(float64)
-		if !ok || (int(flags)&2) != 0 {
-			continue
+		if _, err := c.call(ctx, "messages.edit", q); err != nil {
+			return append(edited, messageIDs[i:]...), fmt.Errorf("can't edit message: %w", err)
 		}
 
-		peerID, ok := arr[3].(float64)
-		if !ok {
-			continue
-		}
+		edited = append(edited, messageIDs[i])
+	}
 
-		text := ""
-		if len(arr) > 5 {
-			if textValue, ok := arr[5].(string); ok {
-				text = textValue
-			}
+	if len(messageIDs) > len(parts) {
+		if err := c.DeleteMessages(ctx, peerID, messageIDs[len(parts):]); err != nil {
+			return append(edited, messageIDs[len(parts):]...), err
 		}
+	}
 
-		update := LongPollUpdate{
-			Type: "message_new",
-			Object: Object{
-				Text:   text,
-				FromId: int(peerID),
-			},
-		}
+	return edited, nil
+}
 
-		updates = append(updates, update)
+// DeleteMessages deletes messages sent by the community for everyone in the
+// conversation.
+func (c *Client) DeleteMessages(ctx context.Context, peerID int, messageIDs []int) error {
+	ids := make([]string, 0, len(messageIDs))
+	for _, messageID := range messageIDs {
+		ids = append(ids, strconv.Itoa(messageID))
 	}
 
-	log.Printf("Processed %d message updates", len(updates))
-	return updates, nil
+	q := url.Values{}
+	q.Add("peer_id", strconv.Itoa(peerID))
+	q.Add(messageIDParam(peerID, "message_ids"), strings.Join(ids, ","))
+	q.Add("delete_for_all", "1")
+
+	if _, err := c.call(ctx, "messages.delete", q); err != nil {
+		return fmt.Errorf("can't delete message: %w", err)
+	}
+
+	return nil
 }
 
-func (c *Client) SendMessage(ctx context.Context, peerID int, message string) error {
-	u, err := url.Parse(fmt.Sprintf("%s/messages.send", c.apiUrl))
-	if err != nil {
-		return fmt.Errorf("can't parse URL: %w", err)
+// messageIDParam returns the name of the parameter that takes the ids of
+// messages to the peer. Messages in group chats are known by their
+// conversation message ids, which take the same name with a conversation_
+// prefix.
+func messageIDParam(peerID int, name string) string {
+	if isChat(peerID) {
+		return "conversation_" + name
 	}
 
-	q := u.Query()
-	q.Add("peer_id", strconv.Itoa(peerID))
-	q.Add("message", message)
-	q.Add("random_id", strconv.FormatInt(int64(randomInt()), 10))
-	q.Add("access_token", c.token)
-	q.Add("v", c.apiVersion)
-	u.RawQuery = q.Encode()
+	return name
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
-	if err != nil {
-		return fmt.Errorf("can't create request: %w", err)
+// send sends message to the peer and returns the ids of the messages sent.
+// A message longer than VK allows is sent as several messages in order, the
+// attachment goes with the first one and the keyboard with the last one. If
+// a part isn't sent, the ids of the parts before it come with the error. The
+// random ids of the messages are derived from key unless it is empty.
+func (c *Client) send(ctx context.Context, key string, peerID int, message string, attachment string, keyboard string) ([]int, error) {
+	parts := splitText(message, maxMessageLength)
+	if len(parts) == 0 {
+		parts = []string{message}
 	}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return fmt.Errorf("can't execute request: %w", err)
+	messageIDs := make([]int, 0, len(parts))
+	for i, part := range parts {
+		var partAttachment, partKeyboard string
+		if i == 0 {
+			partAttachment = attachment
+		}
+		if i == len(parts)-1 {
+			partKeyboard = keyboard
+		}
+
+		messageID, err := c.sendPart(ctx, key, peerID, i, part, partAttachment, partKeyboard)
+		if err != nil {
+			return messageIDs, err
+		}
+
+		messageIDs = append(messageIDs, messageID)
 	}
-	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return fmt.Errorf("can't read response: %w", err)
+	return messageIDs, nil
+}
+
+// sendPart sends the part with index i of a split message and returns its
+// id. It is sent with peer_ids, as only that response has the conversation
+// message id a group chat message is known by.
+func (c *Client) sendPart(ctx context.Context, key string, peerID int, i int, part string, attachment string, keyboard string) (int, error) {
+	q := url.Values{}
+	q.Add("peer_ids", strconv.Itoa(peerID))
+	q.Add("message", part)
+	q.Add("random_id", strconv.FormatInt(int64(randomID(key, peerID, i)), 10))
+	if attachment != "" {
+		q.Add("attachment", attachment)
+	}
+	if keyboard != "" {
+		q.Add("keyboard", keyboard)
 	}
 
-	var msgResp MessageResponse
-	if err := json.Unmarshal(body, &msgResp); err != nil {
-		return fmt.Errorf("can't unmarshal response: %w", err)
+	resp, err := c.call(ctx, "messages.send", q)
+	if err != nil {
+		return 0, err
 	}
 
-	if msgResp.Error != nil {
-		return fmt.Errorf("VK API error: %d - %s", msgResp.Error.ErrorCode, msgResp.Error.ErrorMsg)
+	var peers []peerResult
+	if err := json.Unmarshal(resp, &peers); err != nil {
+		return 0, fmt.Errorf("can't unmarshal send result: %w", err)
+	}
+	if len(peers) == 0 {
+		return 0, fmt.Errorf("no send result for peer %d", peerID)
+	}
+	if p := peers[0]; p.Error != nil {
+		return 0, &APIError{Code: p.Error.Code, Message: p.Error.Description}
 	}
 
-	return nil
+	return peers[0].messageID(), nil
+}
+
+// call invokes an API method and returns its response. Requests are throttled
+// to the community rate limit and repeated when VK still reports it
+// exceeded.
+func (c *Client) call(ctx context.Context, method string, q url.Values) (json.RawMessage, error) {
+	for attempt := 1; ; attempt++ {
+		if err := c.limiter.wait(ctx); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.callOnce(ctx, method, q)
+
+		var apiErr *APIError
+		if attempt < maxAttempts && errors.As(err, &apiErr) && apiErr.Code == codeTooManyRequests {
+			log.Printf("VK rate limit hit on %s, retrying", method)
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(time.Second):
+			}
+
+			continue
+		}
+
+		return resp, err
+	}
 }
 
-func GetLongPollServer(ctx context.Context, token string, version string) (string, string, int, error) {
-	client := http.Client{}
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, getLongPollServerUrl, nil)
+func (c *Client) callOnce(ctx context.Context, method string, q url.Values) (json.RawMessage, error) {
+	u, err := url.Parse(fmt.Sprintf("%s/%s", c.apiUrl, method))
 	if err != nil {
-		return "", "", 0, fmt.Errorf("can't create request: %w", err)
+		return nil, fmt.Errorf("can't parse URL: %w", err)
 	}
 
-	q := url.Values{}
-	q.Add("access_token", token)
-	q.Add("v", version)
-	req.URL.RawQuery = q.Encode()
+	q.Set("access_token", c.token)
+	q.Set("v", c.apiVersion)
+	u.RawQuery = q.Encode()
 
-	resp, err := client.Do(req)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("can't execute request: %w", err)
+		return nil, fmt.Errorf("can't create request: %w", err)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("can't execute request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", "", 0, fmt.Errorf("can't read response: %w", err)
+		return nil, fmt.Errorf("can't read response: %w", err)
 	}
 
-	var response MessageResponse
-	if err := json.Unmarshal(body, &response); err != nil {
-		return "", "", 0, fmt.Errorf("can't unmarshal response: %w", err)
+	var apiResp APIResponse
+	if err := json.Unmarshal(body, &apiResp); err != nil {
+		return nil, fmt.Errorf("can't unmarshal response: %w", err)
 	}
 
-	if response.Error != nil {
-		return "", "", 0, fmt.Errorf("VK API error: %d - %s", response.Error.ErrorCode, response.Error.ErrorMsg)
+	if apiResp.Error != nil {
+		return nil, &APIError{Code: apiResp.Error.ErrorCode, Message: apiResp.Error.ErrorMsg}
 	}
 
-	return response.Response.Server, response.Response.Key, response.Response.TS, nil
-}
-
-func (c *Client) setlongPoll(Url string) {
-	c.longPollUrl = Url
-}
-
-func randomInt() int {
-	return int(float64(100000) * float64(randomFloat()))
-}
-
-func randomFloat() float64 {
-	return float64(time.Now().UnixNano()) / float64(1e9)
-}
-func longPoll(server, key string, ts int) string {
-	return fmt.Sprintf("https://%s?act=a_check&key=%s&ts=%d&wait=20&mode=2&version=2", server, key, ts)
+	return apiResp.Response, nil
 }
@@ -3,17 +3,44 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/joho/godotenv"
 )
 
+// Ways to receive the community events.
+const (
+	ModeLongPoll = "longpoll"
+	ModeCallback = "callback"
+)
+
 type config struct {
-	VkToken     string
+	VkToken string
+	// VkGroupID is the id of the bot's community. Zero means the community
+	// of VkToken.
+	VkGroupID int
+	// VkAPIURL is where the VK API methods are called.
+	VkAPIURL    string
 	AtorToken   string
 	DbHost      string
 	DbPort      string
 	RabbitUrl   string
 	RabbitQueue string
+	// LogLevel is one of debug, info, warn and error.
+	LogLevel string
+	// Admins are the ids of the peers allowed to use the admin commands.
+	Admins []int
+	// VkMode is ModeLongPoll or ModeCallback.
+	VkMode string
+	// VkCallbackAddr and VkCallbackPath are where the Callback API server
+	// listens.
+	VkCallbackAddr string
+	VkCallbackPath string
+	// VkConfirmation is the string the server returns to confirm it to VK.
+	VkConfirmation string
+	// VkSecret is the secret key set for the Callback API.
+	VkSecret string
 }
 
 func MustLoad() *config {
@@ -24,12 +51,79 @@ func MustLoad() *config {
 
 	cfg := &config{
 		VkToken:     os.Getenv("VK_TOKEN"),
+		VkGroupID:   mustParseID(os.Getenv("VK_GROUP_ID")),
 		AtorToken:   os.Getenv("BLOGATOR_TOKEN"),
 		DbHost:      os.Getenv("DB_HOST"),
 		DbPort:      os.Getenv("DB_PORT"),
 		RabbitUrl:   os.Getenv("RABBITMQ_URL"),
 		RabbitQueue: os.Getenv("RabbitMQ_QUEUE"),
+		LogLevel:    os.Getenv("LOG_LEVEL"),
+		Admins:      mustParseIDs(os.Getenv("ADMIN_CHAT_IDS")),
+
+		VkAPIURL:       getEnv("VK_API_URL", "https://api.vk.com/method"),
+		VkMode:         getEnv("VK_MODE", ModeLongPoll),
+		VkCallbackAddr: getEnv("VK_CALLBACK_ADDR", ":8080"),
+		VkCallbackPath: getEnv("VK_CALLBACK_PATH", "/vk/callback"),
+		VkConfirmation: os.Getenv("VK_CONFIRMATION"),
+		VkSecret:       os.Getenv("VK_SECRET"),
 	}
+
+	switch cfg.VkMode {
+	case ModeLongPoll:
+	case ModeCallback:
+		if cfg.VkConfirmation == "" {
+			log.Fatal("VK_CONFIRMATION is required in callback mode")
+		}
+		if cfg.VkSecret == "" {
+			log.Fatal("VK_SECRET is required in callback mode")
+		}
+	default:
+		log.Fatalf("invalid VK_MODE %q", cfg.VkMode)
+	}
+
 	return cfg
 
 }
+
+// Secrets returns the configured values that must never be logged.
+func (c *config) Secrets() []string {
+	return []string{c.VkToken, c.VkSecret, c.VkConfirmation, c.AtorToken, c.RabbitUrl}
+}
+
+func getEnv(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+
+	return fallback
+}
+
+func mustParseID(s string) int {
+	if s = strings.TrimSpace(s); s == "" {
+		return 0
+	}
+
+	id, err := strconv.Atoi(s)
+	if err != nil {
+		log.Fatalf("invalid group id %q", s)
+	}
+
+	return id
+}
+
+func mustParseIDs(s string) []int {
+	var ids []int
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+
+		id, err := strconv.Atoi(item)
+		if err != nil {
+			log.Fatalf("invalid peer id %q", item)
+		}
+		ids = append(ids, id)
+	}
+
+	return ids
+}
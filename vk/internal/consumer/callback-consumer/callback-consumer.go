@@ -0,0 +1,231 @@
+package callback_consumer
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"vk/internal/clients/vk"
+	"vk/internal/events"
+)
+
+const (
+	// maxBodySize limits the events read from VK.
+	maxBodySize = 1 << 20
+	// seenTTL is how long event ids are remembered. VK retries an event a
+	// few times within a minute until it is answered with "ok".
+	seenTTL = 10 * time.Minute
+	// queueSize is how many events wait for the processor before VK is made
+	// to wait too.
+	queueSize = 100
+)
+
+// Parser turns a VK update into an event.
+type Parser interface {
+	Parse(upd vk.LongPollUpdate) (events.Event, error)
+}
+
+// Consumer receives the community events from the VK Callback API. Unlike
+// long polling it can run in any number of replicas behind one address.
+// Retried events are recognized only by the replica that queued them first:
+// VK repeating an event to another replica gets it handled twice.
+type Consumer struct {
+	parser       Parser
+	processor    events.Processor
+	confirmation string
+	secret       string
+	server       *http.Server
+
+	mu sync.Mutex
+	// seen are the ids of the events this replica has already queued.
+	seen map[string]time.Time
+
+	// closeMu makes Stop wait for the handlers queueing events before the
+	// queue is closed.
+	closeMu sync.RWMutex
+	closed  bool
+	events  chan events.Event
+	// stopping is closed by Stop to release the handlers waiting for room
+	// in the queue, done is closed when the queue is drained.
+	stopping chan struct{}
+	done     chan struct{}
+}
+
+// New creates a consumer that serves the Callback API at addr and path.
+// confirmation is the string VK expects in response to the confirmation
+// event, secret is the secret key set in the community settings.
+func New(parser Parser, processor events.Processor, addr, path, confirmation, secret string) *Consumer {
+	c := &Consumer{
+		parser:       parser,
+		processor:    processor,
+		confirmation: confirmation,
+		secret:       secret,
+		seen:         make(map[string]time.Time),
+		events:       make(chan events.Event, queueSize),
+		stopping:     make(chan struct{}),
+		done:         make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(path, c)
+
+	c.server = &http.Server{
+		Addr:         addr,
+		Handler:      mux,
+		ReadTimeout:  15 * time.Second,
+		WriteTimeout: 15 * time.Second,
+		IdleTimeout:  60 * time.Second,
+	}
+
+	return c
+}
+
+// Start serves the Callback API until Stop is called. The queued events are
+// handled even when ctx is cancelled.
+func (c *Consumer) Start(ctx context.Context) error {
+	go c.handleEvents(context.WithoutCancel(ctx))
+
+	log.Printf("Serving VK Callback API on %s", c.server.Addr)
+
+	if err := c.server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
+
+// Stop stops receiving events and waits until the queued ones are processed
+// or ctx is done.
+func (c *Consumer) Stop(ctx context.Context) error {
+	err := c.server.Shutdown(ctx)
+
+	close(c.stopping)
+	c.closeMu.Lock()
+	c.closed = true
+	close(c.events)
+	c.closeMu.Unlock()
+
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return err
+}
+
+func (c *Consumer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var upd vk.CallbackUpdate
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&upd); err != nil {
+		http.Error(w, "invalid event", http.StatusBadRequest)
+		return
+	}
+
+	if subtle.ConstantTimeCompare([]byte(upd.Secret), []byte(c.secret)) != 1 {
+		log.Printf("[ERR] callback consumer: wrong secret in %s event", upd.Type)
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+
+	if upd.Type == vk.EventConfirmation {
+		io.WriteString(w, c.confirmation)
+		return
+	}
+
+	// Events the bot doesn't handle are acknowledged so that VK stops
+	// sending them again.
+	event, err := c.parser.Parse(upd.LongPollUpdate)
+	if err != nil {
+		io.WriteString(w, "ok")
+		return
+	}
+
+	if !c.markSeen(upd.EventID) {
+		io.WriteString(w, "ok")
+		return
+	}
+
+	if !c.enqueue(r.Context(), event) {
+		// VK sends the event again.
+		c.forget(upd.EventID)
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	io.WriteString(w, "ok")
+}
+
+// enqueue queues the event for the processor and reports whether it was
+// queued before the request was cancelled or the consumer stopped.
+func (c *Consumer) enqueue(ctx context.Context, event events.Event) bool {
+	c.closeMu.RLock()
+	defer c.closeMu.RUnlock()
+
+	if c.closed {
+		return false
+	}
+
+	select {
+	case c.events <- event:
+		return true
+	case <-ctx.Done():
+		return false
+	case <-c.stopping:
+		return false
+	}
+}
+
+// markSeen remembers the event id and reports whether it is new. Events
+// without an id are always new.
+func (c *Consumer) markSeen(eventID string) bool {
+	if eventID == "" {
+		return true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if seenAt, ok := c.seen[eventID]; ok && now.Sub(seenAt) < seenTTL {
+		return false
+	}
+
+	for id, seenAt := range c.seen {
+		if now.Sub(seenAt) >= seenTTL {
+			delete(c.seen, id)
+		}
+	}
+	c.seen[eventID] = now
+
+	return true
+}
+
+func (c *Consumer) forget(eventID string) {
+	c.mu.Lock()
+	delete(c.seen, eventID)
+	c.mu.Unlock()
+}
+
+// handleEvents processes the queued events one by one in the order VK sent
+// them.
+func (c *Consumer) handleEvents(ctx context.Context) {
+	defer close(c.done)
+
+	for event := range c.events {
+		log.Printf("got new event: %s", event.Text)
+
+		if err := c.processor.Process(ctx, event); err != nil {
+			log.Printf("can't handle event: %s", err.Error())
+		}
+	}
+}
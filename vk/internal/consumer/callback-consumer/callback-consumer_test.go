@@ -0,0 +1,257 @@
+package callback_consumer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"vk/internal/clients/vk"
+	"vk/internal/events"
+)
+
+const (
+	testConfirmation = "c0nf1rm"
+	testSecret       = "s3cret"
+)
+
+// fakeParser turns message_new updates with a string object into events with
+// that text and rejects the rest.
+type fakeParser struct{}
+
+func (fakeParser) Parse(upd vk.LongPollUpdate) (events.Event, error) {
+	if upd.Type != vk.EventMessageNew {
+		return events.Event{}, errors.New("unsupported event")
+	}
+
+	var text string
+	if err := json.Unmarshal(upd.Object, &text); err != nil {
+		return events.Event{}, err
+	}
+
+	return events.Event{Type: events.Message, Text: text}, nil
+}
+
+// fakeProcessor waits for release before every event and reports the
+// handled ones.
+type fakeProcessor struct {
+	release chan struct{}
+	handled chan string
+}
+
+func newFakeProcessor() *fakeProcessor {
+	release := make(chan struct{})
+	close(release)
+
+	return &fakeProcessor{release: release, handled: make(chan string, queueSize)}
+}
+
+func (p *fakeProcessor) Process(ctx context.Context, e events.Event) error {
+	<-p.release
+	p.handled <- e.Text
+
+	return nil
+}
+
+// newTestConsumer serves the consumer with httptest instead of Start, which
+// listens on a fixed address.
+func newTestConsumer(t *testing.T, processor *fakeProcessor) (*Consumer, *httptest.Server) {
+	c := New(fakeParser{}, processor, "", "/", testConfirmation, testSecret)
+	go c.handleEvents(context.Background())
+
+	srv := httptest.NewServer(c)
+	t.Cleanup(srv.Close)
+
+	return c, srv
+}
+
+func post(t *testing.T, srv *httptest.Server, body string) (int, string) {
+	t.Helper()
+
+	resp, err := srv.Client().Post(srv.URL, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("can't post event: %v", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("can't read response: %v", err)
+	}
+
+	return resp.StatusCode, strings.TrimSpace(string(data))
+}
+
+func message(eventID string, text string) string {
+	return fmt.Sprintf(`{"type":%q,"object":%q,"event_id":%q,"secret":%q}`,
+		vk.EventMessageNew, text, eventID, testSecret)
+}
+
+func TestServeHTTP(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{
+			name:       "confirmation",
+			body:       fmt.Sprintf(`{"type":%q,"secret":%q}`, vk.EventConfirmation, testSecret),
+			wantStatus: http.StatusOK,
+			wantBody:   testConfirmation,
+		},
+		{
+			name:       "confirmation with a wrong secret",
+			body:       fmt.Sprintf(`{"type":%q,"secret":"wrong"}`, vk.EventConfirmation),
+			wantStatus: http.StatusForbidden,
+			wantBody:   "forbidden",
+		},
+		{
+			name:       "event without a secret",
+			body:       fmt.Sprintf(`{"type":%q,"object":"hi","event_id":"1"}`, vk.EventMessageNew),
+			wantStatus: http.StatusForbidden,
+			wantBody:   "forbidden",
+		},
+		{
+			name:       "event",
+			body:       message("1", "hi"),
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:       "event the bot doesn't handle",
+			body:       fmt.Sprintf(`{"type":"wall_post_new","event_id":"2","secret":%q}`, testSecret),
+			wantStatus: http.StatusOK,
+			wantBody:   "ok",
+		},
+		{
+			name:       "invalid JSON",
+			body:       "{",
+			wantStatus: http.StatusBadRequest,
+			wantBody:   "invalid event",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, srv := newTestConsumer(t, newFakeProcessor())
+
+			status, body := post(t, srv, tt.body)
+			if status != tt.wantStatus || body != tt.wantBody {
+				t.Errorf("response %d %q, want %d %q", status, body, tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestServeHTTPRejectsGet(t *testing.T) {
+	_, srv := newTestConsumer(t, newFakeProcessor())
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("can't get: %v", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("GET status %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestRetriedEventsAreHandledOnce(t *testing.T) {
+	processor := newFakeProcessor()
+	_, srv := newTestConsumer(t, processor)
+
+	// VK retries an event with the same id until it gets "ok".
+	for _, body := range []string{message("1", "first"), message("1", "first"), message("2", "second")} {
+		if status, resp := post(t, srv, body); status != http.StatusOK || resp != "ok" {
+			t.Fatalf("response %d %q, want 200 ok", status, resp)
+		}
+	}
+
+	for _, want := range []string{"first", "second"} {
+		select {
+		case got := <-processor.handled:
+			if got != want {
+				t.Errorf("handled %q, want %q", got, want)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("event %q isn't handled", want)
+		}
+	}
+
+	select {
+	case got := <-processor.handled:
+		t.Errorf("handled %q again", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestStopDrainsQueuedEvents(t *testing.T) {
+	processor := newFakeProcessor()
+	processor.release = make(chan struct{})
+	c, srv := newTestConsumer(t, processor)
+
+	want := []string{"a", "b", "c"}
+	for i, text := range want {
+		if status, _ := post(t, srv, message(fmt.Sprint(i), text)); status != http.StatusOK {
+			t.Fatalf("event %q status %d, want 200", text, status)
+		}
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- c.Stop(context.Background())
+	}()
+
+	select {
+	case err := <-stopped:
+		t.Fatalf("Stop() = %v before the queued events are handled", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(processor.release)
+
+	select {
+	case err := <-stopped:
+		if err != nil {
+			t.Fatalf("Stop() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() doesn't return after the queue is drained")
+	}
+
+	for _, text := range want {
+		if got := <-processor.handled; got != text {
+			t.Errorf("handled %q, want %q", got, text)
+		}
+	}
+
+	// VK is told to send an event arriving after Stop again.
+	if status, _ := post(t, srv, message("9", "late")); status != http.StatusServiceUnavailable {
+		t.Errorf("event after Stop status %d, want %d", status, http.StatusServiceUnavailable)
+	}
+}
+
+func TestStopGivesUpWithContext(t *testing.T) {
+	processor := newFakeProcessor()
+	processor.release = make(chan struct{})
+	c, srv := newTestConsumer(t, processor)
+	t.Cleanup(func() { close(processor.release) })
+
+	if status, _ := post(t, srv, message("1", "stuck")); status != http.StatusOK {
+		t.Fatalf("event status %d, want 200", status)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	if err := c.Stop(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Stop() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
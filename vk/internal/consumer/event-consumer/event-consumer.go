@@ -19,22 +19,35 @@ func New(fetcher events.Fetcher, processor events.Processor) Consumer {
 	}
 }
 
-func (c Consumer) Start() error {
+// Start fetches events until ctx is cancelled. The fetched events are
+// handled even when ctx is cancelled meanwhile.
+func (c Consumer) Start(ctx context.Context) error {
+	workCtx := context.WithoutCancel(ctx)
+
 	for {
-		gotEvents, err := c.fetcher.Fetch(context.Background())
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		gotEvents, err := c.fetcher.Fetch(ctx)
 		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
 			log.Printf("[ERR] consumer: %s", err.Error())
+			c.wait(ctx)
 
 			continue
 		}
 
 		if len(gotEvents) == 0 {
-			time.Sleep(1 * time.Second)
+			c.wait(ctx)
 
 			continue
 		}
 
-		if err := c.handleEvents(context.Background(), gotEvents); err != nil {
+		if err := c.handleEvents(workCtx, gotEvents); err != nil {
 			log.Print(err)
 
 			continue
@@ -42,6 +55,14 @@ func (c Consumer) Start() error {
 	}
 }
 
+// wait pauses the fetching for a second or until ctx is cancelled.
+func (c *Consumer) wait(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(1 * time.Second):
+	}
+}
+
 func (c *Consumer) handleEvents(ctx context.Context, events []events.Event) error {
 	for _, event := range events {
 		log.Printf("got new event: %s", event.Text)
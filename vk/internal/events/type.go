@@ -15,6 +15,8 @@ type Type int
 const (
 	Unknown Type = iota
 	Message
+	// Callback is a press of a callback keyboard button.
+	Callback
 )
 
 type Event struct {
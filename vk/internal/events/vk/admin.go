@@ -0,0 +1,239 @@
+package vk
+
+import (
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+	"slices"
+	"strings"
+	"time"
+	"vk/internal/clients/blogator"
+	"vk/internal/clients/rabbitmq"
+	"vk/internal/clients/vk"
+)
+
+// Commands of the broadcast confirmation buttons. They come only with
+// callbacks, so any text can be broadcast.
+const (
+	broadcastSendCallback   = "broadcast:send"
+	broadcastCancelCallback = "broadcast:cancel"
+)
+
+// broadcastProgressEvery is how often the admin's progress message of a
+// broadcast is updated.
+const broadcastProgressEvery = 10 * time.Second
+
+// broadcastChunk is how many peers get the broadcast between the progress
+// updates.
+const broadcastChunk = 100
+
+// isAdmin reports whether the peer may use the admin commands.
+func (p *Processor) isAdmin(peerID int) bool {
+	return slices.Contains(p.admins, peerID)
+}
+
+func (p *Processor) stats(ctx context.Context, peerID int, lang i18n.Lang) error {
+	stats, err := p.db.Stats(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get stats: %w", err)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(i18n.T(lang, i18n.StatsHeader))
+	for _, s := range stats {
+		b.WriteString("\n" + i18n.T(lang, i18n.StatsLine, s.Messenger, s.Active, s.Inactive))
+	}
+
+	return p.vk.SendMessage(ctx, peerID, b.String())
+}
+
+// broadcast keeps the text until the admin confirms or cancels it with the
+// buttons under the question.
+func (p *Processor) broadcast(ctx context.Context, peerID int, lang i18n.Lang, text string) error {
+	if text == "" {
+		return p.vk.SendMessage(ctx, peerID, i18n.T(lang, i18n.BroadcastUsage))
+	}
+
+	p.mu.Lock()
+	p.broadcasts[peerID] = text
+	p.mu.Unlock()
+
+	return p.vk.SendMessageWithKeyboard(ctx, peerID, i18n.T(lang, i18n.BroadcastConfirm, text), broadcastKeyboard(lang))
+}
+
+// broadcastKeyboard is an inline keyboard confirming or cancelling the
+// pending broadcast.
+func broadcastKeyboard(lang i18n.Lang) vk.Keyboard {
+	return vk.Keyboard{
+		Inline: true,
+		Buttons: [][]vk.Button{{
+			callbackButton(i18n.T(lang, i18n.BtnSend), broadcastSendCallback),
+			callbackButton(i18n.T(lang, i18n.BtnCancel), broadcastCancelCallback),
+		}},
+	}
+}
+
+// answerBroadcast handles a press of the broadcast confirmation buttons.
+func (p *Processor) answerBroadcast(ctx context.Context, peerID int, data string) error {
+	lang := p.language(ctx, peerID)
+
+	answer := i18n.T(lang, i18n.UnknownCommand)
+	if p.isAdmin(peerID) {
+		answer = p.confirmBroadcast(ctx, peerID, lang, data)
+	}
+
+	return p.vk.SendMessage(ctx, peerID, answer)
+}
+
+// confirmBroadcast handles the confirmation and returns the text to answer
+// the admin with. A confirmed broadcast is sent in the background, one at a
+// time; a broadcast confirmed while another one is being sent stays pending.
+func (p *Processor) confirmBroadcast(ctx context.Context, peerID int, lang i18n.Lang, data string) string {
+	p.mu.Lock()
+	if data == broadcastSendCallback && p.broadcasting {
+		p.mu.Unlock()
+		return i18n.T(lang, i18n.BroadcastRunning)
+	}
+
+	text, ok := p.broadcasts[peerID]
+	delete(p.broadcasts, peerID)
+
+	start := ok && data == broadcastSendCallback
+	if start {
+		p.broadcasting = true
+	}
+	p.mu.Unlock()
+
+	switch {
+	case !ok:
+		return i18n.T(lang, i18n.BroadcastNothing)
+	case !start:
+		return i18n.T(lang, i18n.BroadcastCancelled)
+	}
+
+	go func() {
+		defer func() {
+			p.mu.Lock()
+			p.broadcasting = false
+			p.mu.Unlock()
+		}()
+
+		if err := p.sendBroadcast(ctx, peerID, lang, text); err != nil {
+			log.Printf("Error sending broadcast: %v", err)
+		}
+	}()
+
+	return i18n.T(lang, i18n.BroadcastStarted)
+}
+
+// sendBroadcast sends text to every active subscriber. The admin sees the
+// progress in a message that is updated while the broadcast goes and gets
+// the result at the end, also when the broadcast is interrupted by shutdown.
+func (p *Processor) sendBroadcast(ctx context.Context, peerID int, lang i18n.Lang, text string) error {
+	chatIDs, err := p.db.AllUsers(ctx, messangerType)
+	if err != nil {
+		return fmt.Errorf("failed to get subscribers: %w", err)
+	}
+
+	log.Printf("Broadcasting to %d chats", len(chatIDs))
+
+	progressID, err := p.vk.SendMessageWithID(ctx, "", peerID, i18n.T(lang, i18n.BroadcastProgress, 0, len(chatIDs)))
+	if err != nil {
+		log.Printf("Error sending broadcast progress to chat %d: %v", peerID, err)
+	}
+	reported := time.Now()
+
+	sent := 0
+	for start := 0; start < len(chatIDs) && ctx.Err() == nil; start += broadcastChunk {
+		end := min(start+broadcastChunk, len(chatIDs))
+
+		for _, r := range p.vk.SendBatch(ctx, "", chatIDs[start:end], text, "") {
+			if r.Err != nil {
+				log.Printf("Error sending broadcast to chat %d: %v", r.PeerID, r.Err)
+				p.deactivateUnavailable(ctx, r.PeerID, r.Err)
+				continue
+			}
+			sent++
+		}
+
+		if progressID != 0 && time.Since(reported) >= broadcastProgressEvery {
+			progress := i18n.T(lang, i18n.BroadcastProgress, end, len(chatIDs))
+			if _, err := p.vk.EditMessage(ctx, peerID, []int{progressID}, progress, ""); err != nil {
+				log.Printf("Error updating broadcast progress in chat %d: %v", peerID, err)
+			}
+			reported = time.Now()
+		}
+	}
+
+	result := i18n.N(lang, i18n.BroadcastDone, len(chatIDs), sent, len(chatIDs))
+	if ctx.Err() != nil {
+		result = i18n.T(lang, i18n.BroadcastStopped, sent, len(chatIDs))
+	}
+
+	// The result is reported even when the broadcast was stopped by shutdown.
+	reportCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+
+	return p.vk.SendMessage(reportCtx, peerID, result)
+}
+
+// preview sends the next queued post to the admin as subscribers will see
+// it. While the consumer keeps the queue drained the latest post from
+// blogator is shown instead.
+func (p *Processor) preview(ctx context.Context, peerID int, lang i18n.Lang) error {
+	ps, queued, err := p.nextPost(ctx)
+	if err != nil {
+		return fmt.Errorf("can't get post to preview: %w", err)
+	}
+	if ps.Link == "" {
+		return p.vk.SendMessage(ctx, peerID, i18n.T(lang, i18n.NoPosts))
+	}
+
+	header := i18n.PreviewLatest
+	if queued {
+		header = i18n.PreviewQueued
+	}
+
+	if err := p.vk.SendMessage(ctx, peerID, i18n.T(lang, header)); err != nil {
+		return err
+	}
+
+	// Every preview is a new message.
+	_, err = p.sendPost(ctx, "", peerID, ps.ImageURL, postText(ps))
+
+	return err
+}
+
+// nextPost returns the first post of the queue or, if it is empty, the
+// latest post from blogator. queued reports where the post came from.
+func (p *Processor) nextPost(ctx context.Context) (ps rabbitmq.DataItem, queued bool, err error) {
+	post, ok, err := p.queue.Peek()
+	if err != nil {
+		log.Printf("can't peek the queue: %v", err)
+	}
+	if ok && len(post.Data) > 0 {
+		return post.Data[0], true, nil
+	}
+
+	items, err := p.ator.GetNewItems(ctx, time.Now().UTC().AddDate(0, 0, -maxLatestDays))
+	if err != nil {
+		return rabbitmq.DataItem{}, false, err
+	}
+	if len(items) == 0 {
+		return rabbitmq.DataItem{}, false, nil
+	}
+
+	latest := slices.MaxFunc(items, func(a, b blogator.DataItem) int {
+		return a.CollectedDate.Compare(b.CollectedDate)
+	})
+
+	return rabbitmq.DataItem{
+		ID:            latest.ID,
+		Title:         latest.Title,
+		Link:          latest.Link,
+		UpdatedDate:   latest.UpdatedDate,
+		CollectedDate: latest.CollectedDate,
+	}, false, nil
+}
@@ -3,6 +3,7 @@ package vk
 import (
 	"context"
 	"fmt"
+	"i18n"
 	"log"
 	"strings"
 )
@@ -12,63 +13,132 @@ const (
 	UnsubscribeCmd = "/unsubscribe"
 	HelpCmd        = "/help"
 	StartCmd       = "/start"
+	LatestCmd      = "/latest"
+	LangCmd        = "/lang"
+	StatsCmd       = "/stats"
+	BroadcastCmd   = "/broadcast"
+	PreviewCmd     = "/preview"
 )
 
+// helpCommands are the commands listed by /help.
+var helpCommands = []struct {
+	name        string
+	description i18n.Key
+}{
+	{LatestCmd, i18n.CmdLatest},
+	{SubscribeCmd, i18n.CmdSubscribe},
+	{UnsubscribeCmd, i18n.CmdUnsubscribe},
+	{LangCmd, i18n.CmdLang},
+}
+
+// adminCommands are listed by /help only in the admins' chats.
+var adminCommands = []struct {
+	name        string
+	description i18n.Key
+}{
+	{StatsCmd, i18n.CmdStats},
+	{BroadcastCmd, i18n.CmdBroadcast},
+	{PreviewCmd, i18n.CmdPreview},
+}
+
 func (p *Processor) doCmd(ctx context.Context, text string, chatID int) error {
 	text = strings.TrimSpace(text)
 
 	log.Printf("got new command '%s' from '%d", text, chatID)
 
-	switch text {
+	lang := p.language(ctx, chatID)
+
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.UnknownCommand))
+	}
+
+	cmd, args := fields[0], fields[1:]
+
+	// Admin commands are hidden from everyone else.
+	if !p.isAdmin(chatID) {
+		switch cmd {
+		case StatsCmd, BroadcastCmd, PreviewCmd:
+			return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.UnknownCommand))
+		}
+	}
+
+	switch cmd {
+	case LatestCmd:
+		return p.latest(ctx, chatID, lang, args)
 	case SubscribeCmd:
-		return p.subscribe(ctx, chatID)
+		return p.subscribe(ctx, chatID, lang)
 	case UnsubscribeCmd:
-		return p.unsubscribe(ctx, chatID)
+		return p.unsubscribe(ctx, chatID, lang)
+	case LangCmd:
+		return p.setLanguage(ctx, chatID, lang, args)
+	case StatsCmd:
+		return p.stats(ctx, chatID, lang)
+	case BroadcastCmd:
+		return p.broadcast(ctx, chatID, lang, strings.TrimSpace(strings.TrimPrefix(text, cmd)))
+	case PreviewCmd:
+		return p.preview(ctx, chatID, lang)
 	case HelpCmd:
-		return p.sendHelp(ctx, chatID)
+		return p.sendHelp(ctx, chatID, lang)
 	case StartCmd:
-		return p.sendHello(ctx, chatID)
+		return p.sendHello(ctx, chatID, lang)
 	default:
-		return p.vk.SendMessage(ctx, chatID, msgUnknownCommand)
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.UnknownCommand))
 	}
 }
 
-func (p *Processor) subscribe(ctx context.Context, chatID int) error {
+func (p *Processor) subscribe(ctx context.Context, chatID int, lang i18n.Lang) error {
 	res, err := p.db.ChatExists(ctx, chatID)
 	if err != nil {
 		return fmt.Errorf("can't check if chat exists: %w", err)
 	}
 	if res {
-		return p.vk.SendMessage(ctx, chatID, msgAlreadySubscribed)
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.AlreadySubscribed))
 	}
 
 	if err = p.db.SaveUser(ctx, chatID); err != nil {
 		return fmt.Errorf("can't save user: %w", err)
 	}
-	return p.vk.SendMessage(ctx, chatID, msgSubscribed)
+	return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.Subscribed))
 }
 
-func (p *Processor) unsubscribe(ctx context.Context, chatID int) error {
+func (p *Processor) unsubscribe(ctx context.Context, chatID int, lang i18n.Lang) error {
 	exists, err := p.db.ChatExists(ctx, chatID)
 	if err != nil {
 		return fmt.Errorf("can't check if chat exists: %w", err)
 	}
 
 	if !exists {
-		return p.vk.SendMessage(ctx, chatID, "You are already unsubscribed!")
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.AlreadyUnsubscribed))
 	}
 
 	if err := p.db.DeleteUser(ctx, chatID); err != nil {
 		return fmt.Errorf("can't unsubscribe: %w", err)
 	}
 
-	return p.vk.SendMessage(ctx, chatID, msgUnsubscribedSuccess)
+	return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.Unsubscribed))
+}
+
+func (p *Processor) sendHelp(ctx context.Context, chatID int, lang i18n.Lang) error {
+	return p.vk.SendMessage(ctx, chatID, helpText(lang, p.isAdmin(chatID)))
 }
 
-func (p *Processor) sendHelp(ctx context.Context, chatID int) error {
-	return p.vk.SendMessage(ctx, chatID, msgHelp)
+func (p *Processor) sendHello(ctx context.Context, chatID int, lang i18n.Lang) error {
+	return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.Hello)+helpText(lang, p.isAdmin(chatID)))
 }
 
-func (p *Processor) sendHello(ctx context.Context, chatID int) error {
-	return p.vk.SendMessage(ctx, chatID, msgHello)
+func helpText(lang i18n.Lang, admin bool) string {
+	var b strings.Builder
+
+	b.WriteString(i18n.T(lang, i18n.HelpIntro))
+	for _, cmd := range helpCommands {
+		fmt.Fprintf(&b, "\n%s - %s", cmd.name, i18n.T(lang, cmd.description))
+	}
+	if admin {
+		for _, cmd := range adminCommands {
+			fmt.Fprintf(&b, "\n%s - %s", cmd.name, i18n.T(lang, cmd.description))
+		}
+	}
+
+	return b.String()
 }
@@ -0,0 +1,70 @@
+package vk
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+	"vk/internal/clients/db"
+	"vk/internal/clients/rabbitmq"
+)
+
+// editDelivered brings the messages a post was already delivered as up to
+// date with its new version. A post that hasn't changed since is not sent
+// again.
+func (p *Processor) editDelivered(ctx context.Context, ps rabbitmq.DataItem, delivered []db.Delivery) []db.Delivery {
+	// Postgres keeps microseconds, so a post read back from the deliveries
+	// looks older than the same post from the queue.
+	updated := ps.UpdatedDate.Truncate(time.Microsecond)
+
+	text := postText(ps)
+
+	var deliveries []db.Delivery
+	for _, d := range delivered {
+		if !updated.After(d.UpdatedDate) {
+			continue
+		}
+
+		peerID, err := strconv.Atoi(d.ChatID)
+		if err != nil {
+			log.Printf("Error parsing chat id %q of post %d: %v", d.ChatID, ps.ID, err)
+			continue
+		}
+
+		log.Printf("Editing post %d in chat %d: %s", ps.ID, peerID, ps.Title)
+
+		messageIDs, err := p.vk.EditMessage(ctx, peerID, d.MessageIDs, text, p.attachment(ctx, peerID, ps.ImageURL))
+		if err != nil {
+			log.Printf("Error editing messages %v in chat %d: %v", d.MessageIDs, peerID, err)
+			p.deactivateUnavailable(ctx, peerID, err)
+		}
+
+		e := delivery(ps, peerID, messageIDs, err)
+		if err == nil {
+			e.Status = db.DeliveryEdited
+		}
+		deliveries = append(deliveries, e)
+	}
+
+	if len(deliveries) == 0 {
+		log.Printf("Post %d is already delivered", ps.ID)
+	}
+
+	return deliveries
+}
+
+// attachment uploads the cover image of a post for the peer. The post goes
+// without it if the upload fails.
+func (p *Processor) attachment(ctx context.Context, peerID int, imageURL string) string {
+	if imageURL == "" {
+		return ""
+	}
+
+	attachment, err := p.vk.UploadPhoto(ctx, peerID, imageURL)
+	if err != nil {
+		log.Printf("Error uploading photo %s: %v", imageURL, err)
+		return ""
+	}
+
+	return attachment
+}
@@ -0,0 +1,26 @@
+package vk
+
+import (
+	"strings"
+	"vk/internal/clients/rabbitmq"
+)
+
+// postText renders a post as a plain text message, VK doesn't support markup
+// in community messages.
+func postText(ps rabbitmq.DataItem) string {
+	var b strings.Builder
+
+	b.WriteString("📰 " + ps.Title)
+
+	if summary := strings.TrimSpace(ps.Summary); summary != "" {
+		b.WriteString("\n\n" + summary)
+	}
+
+	if comment := strings.TrimSpace(ps.Comment); comment != "" {
+		b.WriteString("\n\n🗣 " + comment)
+	}
+
+	b.WriteString("\n\n🔗 " + ps.Link)
+
+	return b.String()
+}
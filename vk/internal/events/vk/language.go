@@ -0,0 +1,94 @@
+package vk
+
+import (
+	"context"
+	"fmt"
+	"i18n"
+	"log"
+
+	"vk/internal/clients/vk"
+)
+
+// russianSpeakingCountries are the VK ids of the countries where Russian is
+// widely read: Russia, Ukraine, Belarus, Kazakhstan, Azerbaijan, Armenia,
+// Kyrgyzstan, Moldova, Tajikistan, Turkmenistan and Uzbekistan.
+var russianSpeakingCountries = map[int]bool{
+	1: true, 2: true, 3: true, 4: true, 5: true, 6: true,
+	11: true, 15: true, 16: true, 17: true, 18: true,
+}
+
+// language returns the language to talk to a peer in: the one chosen with
+// /lang, otherwise the one guessed from the country in the user's profile.
+// Group chats have no profile and get the default language. The result is
+// cached, failed lookups fall back to the default language.
+func (p *Processor) language(ctx context.Context, peerID int) i18n.Lang {
+	p.mu.Lock()
+	lang, ok := p.languages[peerID]
+	p.mu.Unlock()
+
+	if ok {
+		return lang
+	}
+
+	lang, err := p.resolveLanguage(ctx, peerID)
+	if err != nil {
+		log.Printf("can't get language of peer %d: %v", peerID, err)
+		return i18n.Default
+	}
+
+	p.mu.Lock()
+	p.languages[peerID] = lang
+	p.mu.Unlock()
+
+	return lang
+}
+
+func (p *Processor) resolveLanguage(ctx context.Context, peerID int) (i18n.Lang, error) {
+	code, err := p.db.GetLanguage(ctx, peerID)
+	if err != nil {
+		return "", err
+	}
+
+	if lang, ok := i18n.Supported(code); ok {
+		return lang, nil
+	}
+
+	if peerID >= vk.ChatPeerOffset {
+		return i18n.Default, nil
+	}
+
+	user, err := p.vk.GetUser(ctx, peerID)
+	if err != nil {
+		return "", err
+	}
+
+	switch {
+	case user.Country == nil:
+		return i18n.Default, nil
+	case russianSpeakingCountries[user.Country.ID]:
+		return i18n.Russian, nil
+	default:
+		return i18n.English, nil
+	}
+}
+
+func (p *Processor) setLanguage(ctx context.Context, chatID int, lang i18n.Lang, args []string) error {
+	if len(args) != 1 {
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.LangUsage, lang))
+	}
+
+	chosen, ok := i18n.Supported(args[0])
+	if !ok {
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.LangUsage, lang))
+	}
+
+	if err := p.db.SetLanguage(ctx, chatID, string(chosen)); err != nil {
+		return fmt.Errorf("can't set language: %w", err)
+	}
+
+	p.mu.Lock()
+	p.languages[chatID] = chosen
+	p.mu.Unlock()
+
+	return p.vk.SendMessage(ctx, chatID, i18n.T(chosen, i18n.LangChanged))
+}
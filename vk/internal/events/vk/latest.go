@@ -0,0 +1,128 @@
+package vk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"i18n"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"vk/internal/clients/blogator"
+	"vk/internal/clients/vk"
+)
+
+const (
+	defaultLatestCount = 10
+	maxLatestCount     = 50
+	defaultLatestDays  = 2
+	maxLatestDays      = 30
+
+	// latestPageSize is the number of posts in one message.
+	latestPageSize = 5
+)
+
+var errBadLatestArgs = errors.New("bad /latest arguments")
+
+// latestQuery selects the newest count posts of the last days, starting
+// from the offset-th one.
+type latestQuery struct {
+	count  int
+	days   int
+	offset int
+}
+
+func (p *Processor) latest(ctx context.Context, chatID int, lang i18n.Lang, args []string) error {
+	q, err := parseLatestArgs(args)
+	if err != nil {
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.LatestUsage))
+	}
+
+	return p.Post(ctx, chatID, lang, q)
+}
+
+// Post sends a page of the latest posts from blogator with a button for the
+// next page. The button runs /latest for the next offset.
+func (p *Processor) Post(ctx context.Context, chatID int, lang i18n.Lang, q latestQuery) error {
+	since := time.Now().UTC().AddDate(0, 0, -q.days)
+	log.Printf("Fetching posts since %v", since)
+
+	items, err := p.ator.GetNewItems(ctx, since)
+	if err != nil {
+		return fmt.Errorf("can't get new posts: %w", err)
+	}
+
+	slices.SortFunc(items, func(a, b blogator.DataItem) int {
+		return b.CollectedDate.Compare(a.CollectedDate)
+	})
+	items = items[:min(len(items), q.count)]
+
+	if q.offset >= len(items) {
+		return p.vk.SendMessage(ctx, chatID, i18n.T(lang, i18n.NoPosts))
+	}
+
+	end := min(q.offset+latestPageSize, len(items))
+
+	var b strings.Builder
+	b.WriteString(i18n.N(lang, i18n.LatestHeader, q.days, q.offset+1, end, len(items), q.days))
+	for i, item := range items[q.offset:end] {
+		fmt.Fprintf(&b, "\n\n%d. %s\n🔗 %s", q.offset+i+1, item.Title, item.Link)
+	}
+
+	if end < len(items) {
+		next := q
+		next.offset = end
+		return p.vk.SendMessageWithKeyboard(ctx, chatID, b.String(), moreKeyboard(lang, next))
+	}
+
+	return p.vk.SendMessage(ctx, chatID, b.String())
+}
+
+// moreKeyboard is an inline keyboard with a callback button running the
+// query.
+func moreKeyboard(lang i18n.Lang, q latestQuery) vk.Keyboard {
+	command := fmt.Sprintf("%s %d %d %d", LatestCmd, q.count, q.days, q.offset)
+
+	return vk.Keyboard{
+		Inline:  true,
+		Buttons: [][]vk.Button{{callbackButton(i18n.T(lang, i18n.BtnMore), command)}},
+	}
+}
+
+// parseLatestArgs parses the optional count, days and offset arguments of
+// /latest.
+func parseLatestArgs(args []string) (latestQuery, error) {
+	q := latestQuery{count: defaultLatestCount, days: defaultLatestDays}
+
+	if len(args) > 3 {
+		return latestQuery{}, errBadLatestArgs
+	}
+
+	if len(args) > 0 {
+		count, err := strconv.Atoi(args[0])
+		if err != nil || count < 1 || count > maxLatestCount {
+			return latestQuery{}, errBadLatestArgs
+		}
+		q.count = count
+	}
+
+	if len(args) > 1 {
+		days, err := strconv.Atoi(args[1])
+		if err != nil || days < 1 || days > maxLatestDays {
+			return latestQuery{}, errBadLatestArgs
+		}
+		q.days = days
+	}
+
+	if len(args) > 2 {
+		offset, err := strconv.Atoi(args[2])
+		if err != nil || offset < 0 {
+			return latestQuery{}, errBadLatestArgs
+		}
+		q.offset = offset
+	}
+
+	return q, nil
+}
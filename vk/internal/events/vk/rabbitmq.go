@@ -2,13 +2,17 @@ package vk
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"vk/internal/clients/db"
 	"vk/internal/clients/rabbitmq"
+	"vk/internal/clients/vk"
 )
 
 const (
-	messangerType = "Telegram"
+	messangerType = "Vk"
 )
 
 func (p *Processor) SendPostToSubscribers(ctx context.Context, post rabbitmq.Response) error {
@@ -16,30 +20,179 @@ func (p *Processor) SendPostToSubscribers(ctx context.Context, post rabbitmq.Res
 	if err != nil {
 		return fmt.Errorf("failed to get subscribers: %w", err)
 	}
-	log.Println("TEST TEST TEST")
 	if len(chatIDs) == 0 {
 		log.Println("No subscribers to send post to")
 		return nil
 	}
 	log.Println("Sending post to subscribers", post.Data)
 	for _, ps := range post.Data {
-		text := fmt.Sprintf(`
-🗣 %s
-
-📰 %s
+		// Without the deliveries the post would be sent again to the chats
+		// that have it, so the message goes back to the queue instead.
+		delivered, err := p.db.Deliveries(ctx, messangerType, ps.ID)
+		if err != nil {
+			return fmt.Errorf("can't get deliveries of post %d: %w", ps.ID, err)
+		}
 
-🔗 %s
-`, ps.Comment, ps.Title, ps.Link)
-		log.Println("chatIDs: ", chatIDs)
-		log.Println("post: ", text)
-		for _, chatID := range chatIDs {
-			log.Printf("Sending post to chat %d: %s", chatID, ps.Title)
+		var deliveries []db.Delivery
+		if len(delivered) > 0 {
+			deliveries = p.editDelivered(ctx, ps, delivered)
+		} else {
+			deliveries = p.sendToChats(ctx, ps, chatIDs)
+		}
 
-			if err := p.vk.SendMessage(ctx, chatID, text); err != nil {
-				log.Printf("Error sending message to chat %d: %v", chatID, err)
-				continue
-			}
+		if err := p.db.SaveDeliveries(ctx, deliveries); err != nil {
+			log.Printf("Error saving deliveries of post %d: %v", ps.ID, err)
 		}
 	}
 	return nil
 }
+
+func (p *Processor) sendToChats(ctx context.Context, ps rabbitmq.DataItem, chatIDs []int) []db.Delivery {
+	text := postText(ps)
+
+	log.Printf("Sending post to %d chats: %s", len(chatIDs), ps.Title)
+
+	results := p.sendBatch(ctx, deliveryKey(ps), chatIDs, ps.ImageURL, text)
+
+	deliveries := make([]db.Delivery, 0, len(results))
+	for _, r := range results {
+		if r.Err != nil {
+			log.Printf("Error sending message to chat %d: %v", r.PeerID, r.Err)
+			p.deactivateUnavailable(ctx, r.PeerID, r.Err)
+		}
+
+		d := delivery(ps, r.PeerID, r.MessageIDs, r.Err)
+		if r.Partial() {
+			// The beginning of the post is in the chat, so it is edited
+			// rather than sent again. The error tells what is missing.
+			d.Status = db.DeliverySent
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries
+}
+
+// sendBatch sends a post to the peers in batches with its cover image
+// attached. The peers are batched by the peer the image is uploaded for.
+func (p *Processor) sendBatch(ctx context.Context, key string, peerIDs []int, imageURL string, text string) []vk.SendResult {
+	if imageURL == "" {
+		return p.vk.SendBatch(ctx, key, peerIDs, text, "")
+	}
+
+	var uploadPeers []int
+	byUploadPeer := make(map[int][]int)
+	for _, peerID := range peerIDs {
+		uploadPeer := vk.UploadPeer(peerID)
+		if _, ok := byUploadPeer[uploadPeer]; !ok {
+			uploadPeers = append(uploadPeers, uploadPeer)
+		}
+		byUploadPeer[uploadPeer] = append(byUploadPeer[uploadPeer], peerID)
+	}
+
+	results := make([]vk.SendResult, 0, len(peerIDs))
+	for _, uploadPeer := range uploadPeers {
+		peers := byUploadPeer[uploadPeer]
+		attachment := p.attachment(ctx, peers[0], imageURL)
+		results = append(results, p.sendWithAttachment(ctx, key, peers, text, attachment)...)
+	}
+
+	return results
+}
+
+// sendWithAttachment sends a post to the peers with the attachment. Peers
+// the post with the attachment failed for get it as plain text unless they
+// are unavailable or already got its beginning.
+func (p *Processor) sendWithAttachment(ctx context.Context, key string, peerIDs []int, text string, attachment string) []vk.SendResult {
+	results := p.vk.SendBatch(ctx, key, peerIDs, text, attachment)
+	if attachment == "" {
+		return results
+	}
+
+	var retry []int
+	for _, r := range results {
+		if r.Err != nil && r.Parts == 0 && !errors.Is(r.Err, vk.ErrChatUnavailable) {
+			retry = append(retry, r.PeerID)
+		}
+	}
+	if len(retry) == 0 {
+		return results
+	}
+
+	log.Printf("Error sending photo to %d chats, falling back to text", len(retry))
+
+	retried := make(map[int]vk.SendResult, len(retry))
+	for _, r := range p.vk.SendBatch(ctx, key, retry, text, "") {
+		retried[r.PeerID] = r
+	}
+	for i, r := range results {
+		if rr, ok := retried[r.PeerID]; ok {
+			results[i] = rr
+		}
+	}
+
+	return results
+}
+
+// deactivateUnavailable deactivates the peer if err means that messages can
+// never be delivered to it, e.g. the user denied messages from the community.
+func (p *Processor) deactivateUnavailable(ctx context.Context, peerID int, err error) {
+	if !errors.Is(err, vk.ErrChatUnavailable) {
+		return
+	}
+
+	log.Printf("peer %d is unavailable, deactivating: %v", peerID, err)
+
+	if err := p.db.SetChatActive(ctx, peerID, false); err != nil {
+		log.Printf("can't deactivate peer %d: %v", peerID, err)
+	}
+}
+
+// sendPost sends a post with its cover image attached, or as plain text when
+// there is no image or it can't be uploaded. It returns the ids of the
+// messages; a post sent in part is not sent again as text. Sends with the
+// same key deliver the post once; the text fallback shares the key in case
+// the photo was delivered after all.
+func (p *Processor) sendPost(ctx context.Context, key string, peerID int, imageURL string, text string) ([]int, error) {
+	if imageURL != "" {
+		messageIDs, err := p.vk.SendPhotoWithIDs(ctx, key, peerID, text, imageURL)
+		if err == nil || len(messageIDs) > 0 {
+			return messageIDs, err
+		}
+
+		log.Printf("Error sending photo to chat %d, falling back to text: %v", peerID, err)
+	}
+
+	return p.vk.SendMessageWithIDs(ctx, key, peerID, text)
+}
+
+// deliveryKey identifies the delivery of a version of the post, so that a
+// post redelivered by the queue isn't sent to the chats twice.
+func deliveryKey(ps rabbitmq.DataItem) string {
+	return fmt.Sprintf("post:%d:%d", ps.ID, ps.UpdatedDate.Unix())
+}
+
+func delivery(ps rabbitmq.DataItem, peerID int, messageIDs []int, err error) db.Delivery {
+	var messageID int
+	if len(messageIDs) > 0 {
+		messageID = messageIDs[0]
+	}
+
+	d := db.Delivery{
+		PostID:      ps.ID,
+		Messenger:   messangerType,
+		ChatID:      strconv.Itoa(peerID),
+		Target:      db.TargetChat,
+		Status:      db.DeliverySent,
+		MessageID:   messageID,
+		MessageIDs:  messageIDs,
+		UpdatedDate: ps.UpdatedDate,
+	}
+
+	if err != nil {
+		d.Status = db.DeliveryFailed
+		d.Error = err.Error()
+	}
+
+	return d
+}
@@ -0,0 +1,42 @@
+package vk
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// RetractPost deletes the messages a pulled post was delivered as and marks
+// its deliveries retracted. Deliveries that can't be deleted are left as
+// they are.
+func (p *Processor) RetractPost(ctx context.Context, postID int64) error {
+	delivered, err := p.db.Deliveries(ctx, messangerType, postID)
+	if err != nil {
+		return fmt.Errorf("can't get deliveries of post %d: %w", postID, err)
+	}
+
+	log.Printf("Retracting post %d from %d chats", postID, len(delivered))
+
+	retracted := make([]string, 0, len(delivered))
+	for _, d := range delivered {
+		peerID, err := strconv.Atoi(d.ChatID)
+		if err != nil {
+			log.Printf("Error parsing chat id %q of post %d: %v", d.ChatID, postID, err)
+			continue
+		}
+
+		if err := p.vk.DeleteMessages(ctx, peerID, d.MessageIDs); err != nil {
+			log.Printf("Error deleting messages %v in chat %d: %v", d.MessageIDs, peerID, err)
+			continue
+		}
+
+		retracted = append(retracted, d.ChatID)
+	}
+
+	if err := p.db.RetractDeliveries(ctx, messangerType, postID, retracted); err != nil {
+		return fmt.Errorf("can't retract deliveries of post %d: %w", postID, err)
+	}
+
+	return nil
+}
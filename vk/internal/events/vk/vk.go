@@ -2,24 +2,39 @@ package vk
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"i18n"
 	"log"
+	"sync"
 	"vk/internal/clients/blogator"
 	"vk/internal/clients/db"
+	"vk/internal/clients/rabbitmq"
 	"vk/internal/clients/vk"
 	"vk/internal/events"
 )
 
 type Processor struct {
-	vk   *vk.Client
-	ator *blogator.Client
-	db   *db.Client
+	vk     *vk.Client
+	ator   *blogator.Client
+	db     *db.Client
+	queue  *rabbitmq.Client
+	admins []int
+
+	mu        sync.Mutex
+	languages map[int]i18n.Lang
+	// broadcasts are the texts waiting for the admins' confirmation.
+	broadcasts map[int]string
+	// broadcasting is set while a confirmed broadcast is being sent.
+	broadcasting bool
 }
 
 type Meta struct {
 	PeerID int
 	FromID int
+	// EventID is the id of the button press for Callback events.
+	EventID string
 }
 
 var (
@@ -27,11 +42,16 @@ var (
 	ErrUnknownMetaType  = errors.New("unknown meta type")
 )
 
-func New(client *vk.Client, blog *blogator.Client, db *db.Client) *Processor {
+func New(client *vk.Client, blog *blogator.Client, db *db.Client, queue *rabbitmq.Client, admins []int) *Processor {
 	return &Processor{
-		vk:   client,
-		ator: blog,
-		db:   db,
+		vk:     client,
+		ator:   blog,
+		db:     db,
+		queue:  queue,
+		admins: admins,
+
+		languages:  make(map[int]i18n.Lang),
+		broadcasts: make(map[int]string),
 	}
 }
 
@@ -63,6 +83,8 @@ func (p *Processor) Process(ctx context.Context, event events.Event) error {
 	switch event.Type {
 	case events.Message:
 		return p.processMessage(ctx, event)
+	case events.Callback:
+		return p.processCallback(ctx, event)
 	default:
 		return fmt.Errorf("can't process event")
 	}
@@ -81,6 +103,35 @@ func (p *Processor) processMessage(ctx context.Context, event events.Event) erro
 	return nil
 }
 
+// processCallback answers the button press and runs the command of its
+// payload. The broadcast confirmation has commands of its own that can't be
+// typed as text.
+func (p *Processor) processCallback(ctx context.Context, event events.Event) error {
+	meta, err := getMeta(event)
+	if err != nil {
+		return fmt.Errorf("can't process callback: %w", err)
+	}
+
+	answer := vk.MessageEvent{
+		EventID: meta.EventID,
+		UserID:  meta.FromID,
+		PeerID:  meta.PeerID,
+	}
+	if err := p.vk.AnswerMessageEvent(ctx, answer); err != nil {
+		log.Printf("Error answering callback in chat %d: %v", meta.PeerID, err)
+	}
+
+	if event.Text == broadcastSendCallback || event.Text == broadcastCancelCallback {
+		return p.answerBroadcast(ctx, meta.PeerID, event.Text)
+	}
+
+	if err := p.doCmd(ctx, event.Text, meta.PeerID); err != nil {
+		return fmt.Errorf("can't send message: %w", err)
+	}
+
+	return nil
+}
+
 func getMeta(event events.Event) (Meta, error) {
 	res, ok := event.Meta.(Meta)
 	if !ok {
@@ -90,20 +141,70 @@ func getMeta(event events.Event) (Meta, error) {
 	return res, nil
 }
 
+// Parse turns an update received from VK into an event.
+func (p *Processor) Parse(upd vk.LongPollUpdate) (events.Event, error) {
+	return processUpdate(upd)
+}
+
 func processUpdate(upd vk.LongPollUpdate) (events.Event, error) {
-	if upd.Type != "message_new" {
+	switch upd.Type {
+	case vk.EventMessageNew:
+		msg, err := upd.MessageNew()
+		if err != nil {
+			return events.Event{}, err
+		}
+
+		return events.Event{
+			Type: events.Message,
+			Text: msg.Text,
+			Meta: Meta{
+				PeerID: msg.PeerID,
+				FromID: msg.FromID,
+			},
+		}, nil
+	case vk.EventMessageEvent:
+		e, err := upd.MessageEvent()
+		if err != nil {
+			return events.Event{}, err
+		}
+
+		return events.Event{
+			Type: events.Callback,
+			Text: payloadCommand(e.Payload),
+			Meta: Meta{
+				PeerID:  e.PeerID,
+				FromID:  e.UserID,
+				EventID: e.EventID,
+			},
+		}, nil
+	default:
 		return events.Event{}, fmt.Errorf("not a message event: %s", upd.Type)
 	}
+}
 
-	text := upd.Object.Text
-	fromID := upd.Object.FromId
+// callbackButton returns a callback button that runs command when pressed.
+// The command comes back in the payload that payloadCommand reads.
+func callbackButton(label string, command string) vk.Button {
+	payload, _ := json.Marshal(struct {
+		Command string `json:"command"`
+	}{command})
+
+	return vk.Button{Action: vk.ButtonAction{
+		Type:    vk.ButtonCallback,
+		Label:   label,
+		Payload: string(payload),
+	}}
+}
+
+// payloadCommand returns the command of a button payload such as
+// {"command": "/latest"}.
+func payloadCommand(payload json.RawMessage) string {
+	var p struct {
+		Command string `json:"command"`
+	}
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return ""
+	}
 
-	return events.Event{
-		Type: events.Message,
-		Text: text,
-		Meta: Meta{
-			PeerID: fromID,
-			FromID: fromID,
-		},
-	}, nil
+	return p.Command
 }
@@ -0,0 +1,112 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "<redacted>"
+
+// patterns match secrets that may end up in URLs, errors and bodies: bot
+// tokens, access tokens and keys in query strings or JSON, and passwords in
+// connection URLs.
+var patterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`\d{5,}:[A-Za-z0-9_-]{30,}`), redacted},
+	{regexp.MustCompile(`(?i)\b(access_token|token|key|secret)=[^&\s"']+`), "${1}=" + redacted},
+	{regexp.MustCompile(`(?i)"(access_token|token|key|secret)"\s*:\s*"[^"]*"`), `"${1}":"` + redacted + `"`},
+	{regexp.MustCompile(`://[^/\s:@]+:[^/\s@]+@`), "://" + redacted + "@"},
+}
+
+// redactor replaces known secrets and the patterns above in strings.
+type redactor struct {
+	secrets *strings.Replacer
+}
+
+func newRedactor(secrets []string) *redactor {
+	var pairs []string
+	for _, s := range secrets {
+		if s != "" {
+			pairs = append(pairs, s, redacted)
+		}
+	}
+
+	return &redactor{secrets: strings.NewReplacer(pairs...)}
+}
+
+func (r *redactor) redact(s string) string {
+	s = r.secrets.Replace(s)
+	for _, p := range patterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+
+	return s
+}
+
+// RedactHandler removes secrets from the message and the attributes of every
+// record before passing it to the next handler.
+type RedactHandler struct {
+	next     slog.Handler
+	redactor *redactor
+}
+
+func NewRedactHandler(next slog.Handler, secrets ...string) *RedactHandler {
+	return &RedactHandler{
+		next:     next,
+		redactor: newRedactor(secrets),
+	}
+}
+
+func (h *RedactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	res := slog.NewRecord(r.Time, r.Level, h.redactor.redact(r.Message), r.PC)
+
+	r.Attrs(func(a slog.Attr) bool {
+		res.AddAttrs(h.redactAttr(a))
+		return true
+	})
+
+	return h.next.Handle(ctx, res)
+}
+
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	res := make([]slog.Attr, 0, len(attrs))
+	for _, a := range attrs {
+		res = append(res, h.redactAttr(a))
+	}
+
+	return &RedactHandler{next: h.next.WithAttrs(res), redactor: h.redactor}
+}
+
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{next: h.next.WithGroup(name), redactor: h.redactor}
+}
+
+func (h *RedactHandler) redactAttr(a slog.Attr) slog.Attr {
+	v := a.Value.Resolve()
+
+	switch v.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, h.redactor.redact(v.String()))
+	case slog.KindGroup:
+		group := v.Group()
+		res := make([]any, 0, len(group))
+		for _, ga := range group {
+			res = append(res, h.redactAttr(ga))
+		}
+		return slog.Group(a.Key, res...)
+	case slog.KindAny:
+		// Errors and other values are logged as their text, which may
+		// contain a request URL.
+		return slog.String(a.Key, h.redactor.redact(v.String()))
+	default:
+		return a
+	}
+}